@@ -0,0 +1,150 @@
+package wurflgo
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+type MatchResult struct {
+	UA     string
+	Device *Device
+}
+
+func batchWorkers(workers int) int {
+	if workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+//Matches every user agent in uas over workers goroutines, GOMAXPROCS when workers is 0 or less.
+//Identical user agents are only matched once. The result has the same length and order as uas.
+func (r *Repository) MatchBatch(uas []string, workers int) []*Device {
+	unique := []string{}
+	positions := make(map[string]int)
+	for _, ua := range uas {
+		if _, found := positions[ua]; !found {
+			positions[ua] = len(unique)
+			unique = append(unique, ua)
+		}
+	}
+
+	matched := make([]*Device, len(unique))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < batchWorkers(workers) && i < len(unique); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				matched[j] = r.Match(unique[j])
+			}
+		}()
+	}
+	for j := range unique {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	devices := make([]*Device, len(uas))
+	for i, ua := range uas {
+		devices[i] = matched[positions[ua]]
+	}
+	return devices
+}
+
+//The result of matching a user agent, shared by every pendingMatch of that user agent.
+type streamMatch struct {
+	device *Device
+	done   chan struct{}
+}
+
+type pendingMatch struct {
+	ua    string
+	match *streamMatch
+}
+
+//Matches the user agents received on uas over workers goroutines and sends the results in the order the
+//user agents were received. The returned channel is closed once uas is closed and every result was sent,
+//or as soon as ctx is cancelled. Results must be received for the workers to make progress.
+//A user agent that is received again while it is still being matched or waiting to be sent is only matched
+//once. Use EnableMatchCache to also reuse the results of user agents that repeat further apart.
+func (r *Repository) MatchStream(ctx context.Context, uas <-chan string, workers int) <-chan MatchResult {
+	workers = batchWorkers(workers)
+	out := make(chan MatchResult)
+	jobs := make(chan *pendingMatch)
+	pending := make(chan *pendingMatch, workers)
+	var mu sync.Mutex
+	inFlight := make(map[string]*streamMatch)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for p := range jobs {
+				p.match.device = r.Match(p.ua)
+				close(p.match.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			var ua string
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case ua, ok = <-uas:
+				if !ok {
+					return
+				}
+			}
+			mu.Lock()
+			match, found := inFlight[ua]
+			if !found {
+				match = &streamMatch{done: make(chan struct{})}
+				inFlight[ua] = match
+			}
+			mu.Unlock()
+			p := &pendingMatch{ua: ua, match: match}
+			select {
+			case pending <- p:
+			case <-ctx.Done():
+				return
+			}
+			if found {
+				continue
+			}
+			select {
+			case jobs <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		for p := range pending {
+			select {
+			case <-p.match.done:
+			case <-ctx.Done():
+				return
+			}
+			mu.Lock()
+			if inFlight[p.ua] == p.match {
+				delete(inFlight, p.ua)
+			}
+			mu.Unlock()
+			select {
+			case out <- MatchResult{UA: p.ua, Device: p.match.device}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
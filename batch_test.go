@@ -0,0 +1,46 @@
+package wurflgo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMatchStream(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	uas := []string{}
+	for n := 0; n < 20; n++ {
+		uas = append(uas, testUAs[n%3], testUAs[n%3], testUAs[6])
+	}
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, ua := range uas {
+			in <- ua
+		}
+	}()
+	i := 0
+	for result := range r.MatchStream(context.Background(), in, 4) {
+		if result.UA != uas[i] {
+			t.Fatalf("result %d is for %q, want %q", i, result.UA, uas[i])
+		}
+		if want := r.Match(uas[i]); result.Device != want {
+			t.Errorf("result %d = %v, want %v", i, result.Device, want)
+		}
+		i++
+	}
+	if i != len(uas) {
+		t.Errorf("received %d results, want %d", i, len(uas))
+	}
+}
+
+func TestMatchStreamCancel(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string)
+	out := r.MatchStream(ctx, in, 2)
+	in <- testUAs[0]
+	in <- testUAs[0]
+	cancel()
+	for range out {
+	}
+}
@@ -1,51 +1,324 @@
 package wurflgo
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"os"
+	"fmt"
 	"encoding/gob"
 	"bufio"
+	"io"
+	"log/slog"
+	"path/filepath"
 )
 
-func Read(gobFile string) *Repository {
-	decodeFile, err := os.Open(gobFile)
+//Bumped whenever the layout of the cache file or of the types stored in it changes.
+const CACHE_VERSION = 8
+
+//Every cache file starts with these bytes. Caches written before the file had a header start with a gob
+//encoded map instead, they are reported as a CacheVersionError with version 0.
+const CACHE_MAGIC = "wurflgo cache\n"
+
+//Every cache file ends with the sha256 of everything before it.
+const CACHE_CHECKSUM_SIZE = sha256.Size
+
+//Written at the start of every cache file, so a cache can be checked without decoding the devices.
+type CacheHeader struct {
+	//The CACHE_VERSION of the wurflgo that wrote the cache.
+	Version int
+	//The <version><ver> string of the database.
+	WurflVersion string
+	//See Repository.Checksum.
+	Checksum string
+	Patches []string
+	//The selection the database was parsed with, in the form ParseSelection accepts.
+	Selection string
+	Retention RetentionPolicy
+	RetainedCapabilities []string
+	FallBackPolicy FallBackPolicy
+	Devices int
+}
+
+func (r *Repository) cacheHeader() *CacheHeader {
+	header := &CacheHeader{
+		Version: CACHE_VERSION,
+		WurflVersion: r.wurflVersion,
+		Checksum: r.checksum,
+		Patches: []string{},
+		Retention: r.retention,
+		RetainedCapabilities: r.retained,
+		FallBackPolicy: r.fallBackPolicy,
+		Devices: len(r.devices),
+	}
+	if r.selection != nil {
+		header.Selection = r.selection.String()
+	}
+	for _, patch := range r.patches {
+		header.Patches = append(header.Patches, patch.Path)
+	}
+	return header
+}
+
+//Reads only the header of a cache written by Save. Unlike ReadCache it does not verify the checksum.
+func ReadCacheHeader(gobFile string) (*CacheHeader, error) {
+	decodeFile, body, err := openCache(gobFile)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	defer decodeFile.Close()
+	return decodeCacheHeader(gob.NewDecoder(bufio.NewReader(cacheContents(body))), gobFile)
+}
+
+//Opens a cache file and returns the part of it before the trailing checksum.
+//Returns a CacheVersionError when the file does not start with CACHE_MAGIC.
+func openCache(gobFile string) (*os.File, *io.SectionReader, error) {
+	decodeFile, err := openFile(gobFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := decodeFile.Stat()
+	if err != nil {
+		decodeFile.Close()
+		return nil, nil, err
+	}
+	magic := make([]byte, len(CACHE_MAGIC))
+	if n, _ := decodeFile.ReadAt(magic, 0); n == len(magic) && string(magic) != CACHE_MAGIC {
+		decodeFile.Close()
+		return nil, nil, &CacheVersionError{Path: gobFile, Version: 0, Expected: CACHE_VERSION}
+	}
+	if info.Size() < int64(len(CACHE_MAGIC))+CACHE_CHECKSUM_SIZE {
+		decodeFile.Close()
+		return nil, nil, &CorruptCacheError{Path: gobFile, Err: io.ErrUnexpectedEOF}
+	}
+	return decodeFile, io.NewSectionReader(decodeFile, 0, info.Size()-CACHE_CHECKSUM_SIZE), nil
+}
+
+//Returns the gob encoded part of the cache, between CACHE_MAGIC and the trailing checksum.
+func cacheContents(body *io.SectionReader) io.Reader {
+	return io.NewSectionReader(body, int64(len(CACHE_MAGIC)), body.Size()-int64(len(CACHE_MAGIC)))
+}
+
+func decodeCacheHeader(decoder *gob.Decoder, gobFile string) (*CacheHeader, error) {
+	var header CacheHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, &CorruptCacheError{Path: gobFile, Err: err}
+	}
+	if header.Version != CACHE_VERSION {
+		return nil, &CacheVersionError{Path: gobFile, Version: header.Version, Expected: CACHE_VERSION}
+	}
+	return &header, nil
+}
+
+//Compares the checksum at the end of the cache file with the checksum of the rest of it.
+func verifyCache(decodeFile *os.File, body *io.SectionReader, gobFile string) error {
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, io.NewSectionReader(body, 0, body.Size())); err != nil {
+		return err
+	}
+	expected := make([]byte, CACHE_CHECKSUM_SIZE)
+	if _, err := decodeFile.ReadAt(expected, body.Size()); err != nil {
+		return &CorruptCacheError{Path: gobFile, Err: err}
+	}
+	if !bytes.Equal(checksum.Sum(nil), expected) {
+		return &CorruptCacheError{Path: gobFile, Err: ErrCacheChecksum}
+	}
+	return nil
+}
+
+//Reads a repository saved with Save.
+//Returns a CorruptCacheError when the file is truncated or does not match its checksum.
+func ReadCache(gobFile string) (*Repository, error) {
+	decodeFile, body, err := openCache(gobFile)
+	if err != nil {
+		return nil, err
 	}
 	defer decodeFile.Close()
 
 	// Create a decoder
-	decoder := gob.NewDecoder(decodeFile)
+	decoder := gob.NewDecoder(bufio.NewReader(cacheContents(body)))
+	header, err := decodeCacheHeader(decoder, gobFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyCache(decodeFile, body, gobFile); err != nil {
+		return nil, err
+	}
+	selection, err := ParseSelection(header.Selection)
+	if err != nil {
+		return nil, &CorruptCacheError{Path: gobFile, Err: err}
+	}
 
 	// Place to decode into
 	devices := make(map[string]*Device)
 
-	// Decode -- We need to pass a pointer otherwise accounts2 isn't modified
-	decoder.Decode(&devices)
-
-	if devices == nil || len(devices) <= 10 {
-		return nil
+	// Decode -- We need to pass a pointer otherwise devices isn't modified
+	if err := decoder.Decode(&devices); err != nil {
+		return nil, &CorruptCacheError{Path: gobFile, Err: err}
 	}
 
-	repo := &Repository {
-		devices: devices,
+	repo := NewRepository()
+	repo.devices = devices
+	repo.selection = selection
+	repo.wurflVersion = header.WurflVersion
+	repo.checksum = header.Checksum
+	repo.fallBackPolicy = header.FallBackPolicy
+	//The cache only records the paths of the patches, not what they changed.
+	for _, path := range header.Patches {
+		repo.patches = append(repo.patches, newPatchReport(path))
 	}
+	repo.SetRetention(header.Retention, header.RetainedCapabilities...)
 	repo.Initialize()
+	return repo, nil
+}
+
+//Deprecated: use ReadCache, which returns the reason the cache could not be read.
+func Read(gobFile string) *Repository {
+	repo, err := ReadCache(gobFile)
+	if err != nil {
+		slog.Default().Error("reading wurfl cache failed", "path", gobFile, "error", err)
+		return nil
+	}
 	return repo
 }
 
+//Writes the repository to a temporary file next to gobFile and renames it over gobFile once it is
+//flushed and synced, so a crash never leaves a partially written cache behind.
 func (r *Repository) Save(gobFile string) error {
 	// Create a file for IO
-	encodeFile, err := os.Create(gobFile)
+	encodeFile, err := os.CreateTemp(filepath.Dir(gobFile), filepath.Base(gobFile)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer encodeFile.Close()
-	encoder := gob.NewEncoder(bufio.NewWriter(encodeFile))
+	if err := r.writeCache(encodeFile); err != nil {
+		encodeFile.Close()
+		os.Remove(encodeFile.Name())
+		return err
+	}
+	if err := encodeFile.Close(); err != nil {
+		os.Remove(encodeFile.Name())
+		return err
+	}
+	if err := os.Rename(encodeFile.Name(), gobFile); err != nil {
+		os.Remove(encodeFile.Name())
+		return err
+	}
+	syncDir(filepath.Dir(gobFile))
+	return nil
+}
+
+func (r *Repository) writeCache(encodeFile *os.File) error {
+	// CreateTemp only lets the owner read the file
+	if err := encodeFile.Chmod(0644); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(encodeFile)
+	checksum := sha256.New()
+	contents := io.MultiWriter(writer, checksum)
+	if _, err := io.WriteString(contents, CACHE_MAGIC); err != nil {
+		return err
+	}
+	encoder := gob.NewEncoder(contents)
 
 	// Write to the file
+	if err := encoder.Encode(r.cacheHeader()); err != nil {
+		return err
+	}
 	if err := encoder.Encode(r.devices); err != nil {
-		os.Remove(gobFile)
 		return err
 	}
-	return nil
-}
\ No newline at end of file
+	if _, err := writer.Write(checksum.Sum(nil)); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return encodeFile.Sync()
+}
+
+//Makes the rename durable. Not every platform can sync a directory, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+//Reads the repository from gobFile when the cache was written from the same database, patches, selection and
+//retention, and otherwise loads the database and rewrites the cache. A cache that cannot be written is
+//logged and the loaded repository is returned anyway.
+func LoadCached(database string, gobFile string, groups string, options ...Option) (*Repository, error) {
+	return LoadCachedContext(context.Background(), database, gobFile, groups, options...)
+}
+
+func LoadCachedContext(ctx context.Context, database string, gobFile string, groups string, options ...Option) (*Repository, error) {
+	repository := NewRepository()
+	wp, err := NewProcessor(groups, database, repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		option(wp)
+	}
+	repo, reason := wp.readMatchingCache(gobFile)
+	if repo != nil {
+		wp.source.Close()
+		wp.Logger.Info("wurfl cache is up to date", "path", gobFile)
+		return repo, nil
+	}
+	wp.Logger.Info("rebuilding wurfl cache", "path", gobFile, "reason", reason)
+	if err := wp.ProcessContext(ctx); err != nil {
+		return nil, err
+	}
+	if err := repository.Save(gobFile); err != nil {
+		wp.Logger.Warn("writing wurfl cache failed", "path", gobFile, "error", err)
+	}
+	return repository, nil
+}
+
+//Returns the cached repository when its header matches the processor, or the reason it does not.
+func (wp *WurflProcessor) readMatchingCache(gobFile string) (*Repository, string) {
+	header, err := ReadCacheHeader(gobFile)
+	if err != nil {
+		return nil, err.Error()
+	}
+	checksum, err := sourceChecksum(wp.FS, append([]string{wp.Name}, wp.Patches...)...)
+	if err != nil {
+		return nil, err.Error()
+	}
+	retention, retained := wp.Out.Retention()
+	expected := &CacheHeader{
+		Checksum: checksum,
+		Patches: wp.Patches,
+		Selection: wp.Selection.String(),
+		Retention: retention,
+		RetainedCapabilities: retained,
+		FallBackPolicy: wp.FallBackPolicy,
+	}
+	if reason := header.mismatch(expected); reason != "" {
+		return nil, reason
+	}
+	repo, err := ReadCache(gobFile)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return repo, ""
+}
+
+func (h *CacheHeader) mismatch(expected *CacheHeader) string {
+	switch {
+	case h.Checksum != expected.Checksum:
+		return "source checksum changed"
+	case fmt.Sprint(h.Patches) != fmt.Sprint(expected.Patches):
+		return "patches changed"
+	case h.Selection != expected.Selection:
+		return "selection changed"
+	case h.Retention != expected.Retention || fmt.Sprint(h.RetainedCapabilities) != fmt.Sprint(expected.RetainedCapabilities):
+		return "retention changed"
+	case h.FallBackPolicy != expected.FallBackPolicy:
+		return "fall_back policy changed"
+	}
+	return ""
+}
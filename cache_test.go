@@ -0,0 +1,257 @@
+package wurflgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testPatch = `<wurfl_patch><devices>
+<device id="nokia_6600_sub" user_agent="Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0">
+  <group id="product_info">
+    <capability name="model_name" value="6600"/>
+  </group>
+</device>
+</devices></wurfl_patch>`
+
+//Writes the test database and a patch to a temporary directory.
+func writeTestDatabase(t *testing.T) (database string, patch string) {
+	dir := t.TempDir()
+	database = filepath.Join(dir, "wurfl.xml")
+	patch = filepath.Join(dir, "patch.xml")
+	if err := os.WriteFile(database, []byte(testDatabase), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(patch, []byte(testPatch), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return database, patch
+}
+
+//Records why LoadCached rebuilt the cache, "" when it was up to date.
+type cacheLogHandler struct {
+	mu     sync.Mutex
+	reason string
+}
+
+func (h *cacheLogHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *cacheLogHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *cacheLogHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *cacheLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch record.Message {
+	case "wurfl cache is up to date":
+		h.reason = ""
+	case "rebuilding wurfl cache":
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == "reason" {
+				h.reason = attr.Value.String()
+			}
+			return true
+		})
+	}
+	return nil
+}
+
+func loadCached(t *testing.T, database, gobFile, selection string, options ...Option) (*Repository, string) {
+	h := &cacheLogHandler{reason: "not logged"}
+	r, err := LoadCached(database, gobFile, selection, append([]Option{WithLogger(slog.New(h))}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, h.reason
+}
+
+//Caches written before the cache file had a header hold nothing but the gob encoded devices.
+func TestReadLegacyCache(t *testing.T) {
+	gobFile := filepath.Join(t.TempDir(), "wurfl.gob")
+	f, err := os.Create(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewEncoder(f).Encode(loadTestRepository(t, "all").devices); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var versionErr *CacheVersionError
+	if _, err := ReadCache(gobFile); !errors.As(err, &versionErr) || versionErr.Version != 0 || versionErr.Expected != CACHE_VERSION {
+		t.Errorf("ReadCache = %v, want a CacheVersionError with version 0", err)
+	}
+	if _, err := ReadCacheHeader(gobFile); !errors.As(err, &versionErr) {
+		t.Errorf("ReadCacheHeader = %v, want a CacheVersionError", err)
+	}
+}
+
+func TestLoadCached(t *testing.T) {
+	database, patch := writeTestDatabase(t)
+	gobFile := filepath.Join(filepath.Dir(database), "wurfl.gob")
+	patched := WithPatches(patch)
+
+	steps := []struct {
+		name      string
+		selection string
+		options   []Option
+		reason    string
+	}{
+		{name: "no cache", selection: "product_info", options: []Option{patched}, reason: "wurflgo: file"},
+		{name: "reuse", selection: "product_info", options: []Option{patched}},
+		//The same patch under another name, so only the list of patches differs.
+		{name: "patches", selection: "product_info", options: []Option{WithPatches(filepath.Dir(patch) + "/./patch.xml")}, reason: "patches changed"},
+		{name: "no patches", selection: "product_info", reason: "source checksum changed"},
+		{name: "selection", selection: "product_info,display", reason: "selection changed"},
+		{name: "retention", selection: "product_info,display", options: []Option{WithRetention(RETAIN_ALL)}, reason: "retention changed"},
+		{name: "fall_back policy", selection: "product_info,display", options: []Option{WithRetention(RETAIN_ALL), WithFallBackPolicy(FALLBACK_DROP)}, reason: "fall_back policy changed"},
+		{name: "reuse again", selection: "product_info,display", options: []Option{WithRetention(RETAIN_ALL), WithFallBackPolicy(FALLBACK_DROP)}},
+	}
+	for _, step := range steps {
+		r, reason := loadCached(t, database, gobFile, step.selection, step.options...)
+		if !strings.HasPrefix(reason, step.reason) || (step.reason == "" && reason != "") {
+			t.Errorf("%s: reason = %q, want %q", step.name, reason, step.reason)
+		}
+		if dev := r.Match(testUAs[6]); dev == nil || dev.Id != "nokia_6600_sub" {
+			t.Errorf("%s: Match = %v", step.name, dev)
+		}
+	}
+
+	if err := os.WriteFile(database, []byte(strings.Replace(testDatabase, "4.09.1", "4.09.2", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, reason := loadCached(t, database, gobFile, "product_info,display", WithRetention(RETAIN_ALL), WithFallBackPolicy(FALLBACK_DROP)); reason != "source checksum changed" {
+		t.Errorf("reason = %q after the database changed", reason)
+	}
+}
+
+//A repository read from a cache and saved again has to keep matching the loader options it was built with.
+func TestResavedCacheIsReused(t *testing.T) {
+	database, patch := writeTestDatabase(t)
+	gobFile := filepath.Join(filepath.Dir(database), "wurfl.gob")
+	loadCached(t, database, gobFile, "product_info", WithPatches(patch), WithFallBackPolicy(FALLBACK_REPARENT))
+
+	r, err := ReadCache(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Patches()) != 1 || r.Patches()[0].Path != patch {
+		t.Errorf("Patches() = %+v, want %s", r.Patches(), patch)
+	}
+	if err := r.Save(gobFile); err != nil {
+		t.Fatal(err)
+	}
+	header, err := ReadCacheHeader(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(header.Patches) != 1 || header.FallBackPolicy != FALLBACK_REPARENT || header.Selection != "product_info.*" {
+		t.Errorf("header = %+v after saving again", header)
+	}
+	if _, reason := loadCached(t, database, gobFile, "product_info", WithPatches(patch), WithFallBackPolicy(FALLBACK_REPARENT)); reason != "" {
+		t.Errorf("cache saved again was rebuilt: %s", reason)
+	}
+}
+
+func TestSaveAndReadCache(t *testing.T) {
+	dir := t.TempDir()
+	gobFile := filepath.Join(dir, "wurfl.gob")
+	if err := os.WriteFile(gobFile, []byte("an older cache"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := loadTestRepository(t, "product_info")
+	if err := r.Save(gobFile); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "wurfl.gob" {
+		t.Errorf("Save left %v behind", entries)
+	}
+	info, err := os.Stat(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("cache mode = %v, want 0644", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte(CACHE_MAGIC)) {
+		t.Errorf("cache does not start with CACHE_MAGIC")
+	}
+	body, checksum := data[:len(data)-CACHE_CHECKSUM_SIZE], data[len(data)-CACHE_CHECKSUM_SIZE:]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], checksum) {
+		t.Errorf("cache does not end with the sha256 of its contents")
+	}
+
+	cached, err := ReadCache(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.count() != r.count() || cached.Selection().String() != r.Selection().String() || cached.WurflVersion() != "test" {
+		t.Errorf("ReadCache = %d devices, selection %s, version %q", cached.count(), cached.Selection(), cached.WurflVersion())
+	}
+	for _, ua := range testUAs {
+		if got, want := cached.Match(ua), r.Match(ua); deviceId(got) != deviceId(want) {
+			t.Errorf("Match(%q) = %s from the cache, %s from the database", ua, deviceId(got), deviceId(want))
+		}
+	}
+	if brand, _ := cached.DeviceByID("samsung_gt_i9100_ver1").Capability("brand_name"); brand != "Samsung" {
+		t.Errorf("cached brand_name = %q", brand)
+	}
+}
+
+func TestReadCorruptCache(t *testing.T) {
+	dir := t.TempDir()
+	gobFile := filepath.Join(dir, "wurfl.gob")
+	if err := loadTestRepository(t, "product_info").Save(gobFile); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(gobFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := append([]byte{}, data...)
+	flipped[len(flipped)/2] ^= 0x01
+
+	for _, test := range []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "flipped byte", data: flipped, err: ErrCacheChecksum},
+		{name: "flipped checksum", data: append(append([]byte{}, data[:len(data)-1]...), data[len(data)-1]^0x01), err: ErrCacheChecksum},
+		{name: "truncated", data: data[:len(data)-10]},
+		{name: "checksum only", data: data[:len(CACHE_MAGIC)+CACHE_CHECKSUM_SIZE-1], err: io.ErrUnexpectedEOF},
+		{name: "shorter than the magic", data: data[:4], err: io.ErrUnexpectedEOF},
+		{name: "empty", data: []byte{}, err: io.ErrUnexpectedEOF},
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".gob")
+		if err := os.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		var corrupt *CorruptCacheError
+		_, err := ReadCache(path)
+		if !errors.As(err, &corrupt) || corrupt.Path != path {
+			t.Errorf("%s: ReadCache = %v, want a CorruptCacheError", test.name, err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s: ReadCache = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
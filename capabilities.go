@@ -0,0 +1,367 @@
+package wurflgo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type CapabilityType int
+
+const (
+	CAPABILITY_STRING CapabilityType = iota
+	CAPABILITY_BOOL
+	CAPABILITY_INT
+	CAPABILITY_FLOAT
+	CAPABILITY_ENUM
+)
+
+func (t CapabilityType) String() string {
+	switch t {
+	case CAPABILITY_BOOL:
+		return "bool"
+	case CAPABILITY_INT:
+		return "int"
+	case CAPABILITY_FLOAT:
+		return "float"
+	case CAPABILITY_ENUM:
+		return "enum"
+	}
+	return "string"
+}
+
+//Describes a capability of the WURFL database. Values lists the allowed values of an enum.
+type CapabilityDefinition struct {
+	Name   string
+	Group  string
+	Type   CapabilityType
+	Values []string
+}
+
+var (
+	ErrUnknownCapability     = errors.New("unknown capability")
+	ErrCapabilityNotRetained = errors.New("capability not retained")
+	ErrCapabilityType        = errors.New("capability has a different type")
+	ErrCapabilityValue       = errors.New("invalid capability value")
+)
+
+type capabilityGroup struct {
+	id           string
+	capabilities []CapabilityDefinition
+}
+
+func boolCap(name string) CapabilityDefinition {
+	return CapabilityDefinition{Name: name, Type: CAPABILITY_BOOL}
+}
+
+func intCap(name string) CapabilityDefinition {
+	return CapabilityDefinition{Name: name, Type: CAPABILITY_INT}
+}
+
+func floatCap(name string) CapabilityDefinition {
+	return CapabilityDefinition{Name: name, Type: CAPABILITY_FLOAT}
+}
+
+func stringCap(name string) CapabilityDefinition {
+	return CapabilityDefinition{Name: name, Type: CAPABILITY_STRING}
+}
+
+func enumCap(name string, values ...string) CapabilityDefinition {
+	return CapabilityDefinition{Name: name, Type: CAPABILITY_ENUM, Values: values}
+}
+
+//The capabilities of the WURFL database we know the type of, by group.
+var knownCapabilityGroups = []capabilityGroup{
+	{"product_info", []CapabilityDefinition{
+		stringCap("brand_name"),
+		stringCap("model_name"),
+		stringCap("marketing_name"),
+		stringCap("model_extra_info"),
+		stringCap("manufacturer_name"),
+		boolCap("unique"),
+		stringCap("ununiqueness_handler"),
+		boolCap("is_wireless_device"),
+		boolCap("is_tablet"),
+		boolCap("ux_full_desktop"),
+		boolCap("device_claims_web_support"),
+		boolCap("has_qwerty_keyboard"),
+		boolCap("has_cellular_radio"),
+		boolCap("can_assign_phone_number"),
+		boolCap("can_skip_aligned_link_row"),
+		stringCap("uaprof"),
+		stringCap("uaprof2"),
+		stringCap("uaprof3"),
+		intCap("nokia_series"),
+		intCap("nokia_edition"),
+		intCap("nokia_feature_pack"),
+		stringCap("device_os"),
+		stringCap("device_os_version"),
+		stringCap("mobile_browser"),
+		stringCap("mobile_browser_version"),
+		enumCap("pointing_method", "", "joystick", "stylus", "touchscreen", "clickwheel"),
+		stringCap("release_date"),
+	}},
+	{"display", []CapabilityDefinition{
+		intCap("resolution_width"),
+		intCap("resolution_height"),
+		intCap("columns"),
+		intCap("rows"),
+		intCap("max_image_width"),
+		intCap("max_image_height"),
+		intCap("physical_screen_width"),
+		intCap("physical_screen_height"),
+		boolCap("dual_orientation"),
+		floatCap("density_class"),
+	}},
+	{"markup", []CapabilityDefinition{
+		enumCap("preferred_markup",
+			"wml_1_1", "wml_1_2", "wml_1_3",
+			"html_wi_w3_xhtmlbasic", "html_wi_oma_xhtmlmp_1_0",
+			"html_wi_imode_html_1", "html_wi_imode_html_2", "html_wi_imode_html_3",
+			"html_wi_imode_html_4", "html_wi_imode_html_5", "html_wi_imode_html_6",
+			"html_wi_imode_htmlx_1", "html_wi_imode_htmlx_1_1", "html_wi_imode_compact_generic",
+			"html_web_3_2", "html_web_4_0", "html_web_5_0"),
+		intCap("xhtml_support_level"),
+		boolCap("wml_1_1"),
+		boolCap("wml_1_2"),
+		boolCap("wml_1_3"),
+		boolCap("html_wi_w3_xhtmlbasic"),
+		boolCap("html_wi_oma_xhtmlmp_1_0"),
+		boolCap("html_wi_imode_compact_generic"),
+		boolCap("html_web_3_2"),
+		boolCap("html_web_4_0"),
+		boolCap("voicexml"),
+		boolCap("multipart_support"),
+	}},
+	{"xhtml_ui", []CapabilityDefinition{
+		stringCap("xhtml_make_phone_call_string"),
+		stringCap("xhtml_send_sms_string"),
+		stringCap("xhtml_send_mms_string"),
+		stringCap("xhtml_preferred_charset"),
+		enumCap("xhtml_can_embed_video", "none", "play_and_stop", "play_and_back"),
+		enumCap("xhtml_file_upload", "not_supported", "supported", "user_action_needed"),
+		enumCap("xhtml_supports_iframe", "none", "partial", "full"),
+		boolCap("xhtml_table_support"),
+		boolCap("xhtml_format_as_css_property"),
+		boolCap("xhtml_supports_forms_in_table"),
+		boolCap("xhtml_support_wml2_namespace"),
+		boolCap("xhtml_nowrap_mode"),
+	}},
+	{"ajax", []CapabilityDefinition{
+		boolCap("ajax_support_javascript"),
+		boolCap("ajax_manipulate_css"),
+		boolCap("ajax_manipulate_dom"),
+		boolCap("ajax_support_getelementbyid"),
+		boolCap("ajax_support_inner_html"),
+		boolCap("ajax_support_events"),
+		boolCap("ajax_support_event_listener"),
+		enumCap("ajax_xhr_type", "none", "standard", "msxml2", "legacy_microsoft"),
+		enumCap("ajax_preferred_geoloc_api", "none", "gears", "w3c_api"),
+	}},
+	{"html_ui", []CapabilityDefinition{
+		enumCap("canvas_support", "none", "partial", "full"),
+		boolCap("viewport_supported"),
+		stringCap("viewport_width"),
+		stringCap("viewport_initial_scale"),
+		stringCap("viewport_minimum_scale"),
+		stringCap("viewport_maximum_scale"),
+		boolCap("viewport_userscalable"),
+		boolCap("mobileoptimized"),
+		boolCap("handheldfriendly"),
+		boolCap("image_inlining"),
+		boolCap("is_sencha_touch_ok"),
+	}},
+	{"css", []CapabilityDefinition{
+		boolCap("css_supports_width_as_percentage"),
+		boolCap("css_spriting"),
+		enumCap("css_border_image", "none", "webkit", "mozilla", "opera", "css3"),
+		enumCap("css_rounded_corners", "none", "webkit", "mozilla", "opera", "css3"),
+		enumCap("css_gradient", "none", "webkit", "mozilla", "css3"),
+	}},
+	{"image_format", []CapabilityDefinition{
+		boolCap("gif"),
+		boolCap("gif_animated"),
+		boolCap("jpg"),
+		boolCap("png"),
+		boolCap("bmp"),
+		boolCap("svgt_1_1"),
+		boolCap("webp_lossy_support"),
+		boolCap("webp_lossless_support"),
+		intCap("colors"),
+		boolCap("greyscale"),
+	}},
+	{"playback", []CapabilityDefinition{
+		boolCap("playback_mp4"),
+		boolCap("playback_3gpp"),
+		boolCap("playback_mov"),
+		boolCap("playback_wmv"),
+		boolCap("playback_real_media"),
+		boolCap("progressive_download"),
+		boolCap("hinted_progressive_download"),
+		enumCap("playback_vcodec_h264_bp", "none", "1.0", "1.1", "1.2", "1.3", "2.0", "3.0", "3.1"),
+		enumCap("playback_acodec_aac", "none", "lc", "lc_ltp"),
+	}},
+	{"sound_format", []CapabilityDefinition{
+		boolCap("mp3"),
+		boolCap("aac"),
+		boolCap("amr"),
+		boolCap("wav"),
+		boolCap("midi_monophonic"),
+		boolCap("midi_polyphonic"),
+	}},
+	{"bearer", []CapabilityDefinition{
+		boolCap("wifi"),
+		intCap("max_data_rate"),
+	}},
+	{"storage", []CapabilityDefinition{
+		intCap("max_deck_size"),
+		intCap("max_url_length_in_requests"),
+		intCap("max_length_of_password"),
+		intCap("max_no_of_bookmarks"),
+	}},
+	{"security", []CapabilityDefinition{
+		boolCap("https_support"),
+		boolCap("phone_id_provided"),
+	}},
+	{"cache", []CapabilityDefinition{
+		boolCap("total_cache_disable_support"),
+		boolCap("time_to_live_support"),
+	}},
+	{"smarttv", []CapabilityDefinition{
+		boolCap("is_smarttv"),
+	}},
+	{"rss", []CapabilityDefinition{
+		boolCap("rss_support"),
+	}},
+	{"pdf", []CapabilityDefinition{
+		boolCap("pdf_support"),
+	}},
+	{"flash_lite", []CapabilityDefinition{
+		stringCap("flash_lite_version"),
+		boolCap("full_flash_support"),
+	}},
+	{"streaming", []CapabilityDefinition{
+		boolCap("streaming_video"),
+		boolCap("streaming_real_media"),
+		boolCap("streaming_mp4"),
+		boolCap("streaming_3gpp"),
+	}},
+	{"transcoding", []CapabilityDefinition{
+		boolCap("is_transcoder"),
+		stringCap("transcoder_ua_header"),
+	}},
+	{"wta", []CapabilityDefinition{
+		boolCap("nokia_voice_call"),
+		boolCap("wta_phonebook"),
+	}},
+}
+
+var knownCapabilities = indexCapabilities(knownCapabilityGroups)
+
+func indexCapabilities(groups []capabilityGroup) map[string]*CapabilityDefinition {
+	index := make(map[string]*CapabilityDefinition)
+	for _, group := range groups {
+		for i := range group.capabilities {
+			definition := group.capabilities[i]
+			definition.Group = group.id
+			index[definition.Name] = &definition
+		}
+	}
+	return index
+}
+
+//Returns the definition of a known WURFL capability.
+func LookupCapability(name string) (*CapabilityDefinition, bool) {
+	definition, found := knownCapabilities[name]
+	return definition, found
+}
+
+func (dev *Device) typedCapability(name string, kind CapabilityType) (string, error) {
+	definition, found := LookupCapability(name)
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCapability, name)
+	}
+	if definition.Type != kind {
+		return "", fmt.Errorf("%w: %s is %s, not %s", ErrCapabilityType, name, definition.Type, kind)
+	}
+	value, found := dev.Capability(name)
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrCapabilityNotRetained, name)
+	}
+	return value, nil
+}
+
+func (dev *Device) Bool(name string) (bool, error) {
+	value, err := dev.typedCapability(name, CAPABILITY_BOOL)
+	if err != nil {
+		return false, err
+	}
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: %s=%q", ErrCapabilityValue, name, value)
+}
+
+func (dev *Device) Int(name string) (int, error) {
+	value, err := dev.typedCapability(name, CAPABILITY_INT)
+	if err != nil {
+		return 0, err
+	}
+	return parseIntCapability(name, value)
+}
+
+func (dev *Device) Float(name string) (float64, error) {
+	value, err := dev.typedCapability(name, CAPABILITY_FLOAT)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s=%q", ErrCapabilityValue, name, value)
+	}
+	return f, nil
+}
+
+//Returns the value of an enum capability, checked against the values WURFL allows for it.
+func (dev *Device) Enum(name string) (string, error) {
+	value, err := dev.typedCapability(name, CAPABILITY_ENUM)
+	if err != nil {
+		return "", err
+	}
+	definition, _ := LookupCapability(name)
+	for _, allowed := range definition.Values {
+		if value == allowed {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s=%q", ErrCapabilityValue, name, value)
+}
+
+//The resolution_width property as an integer.
+func (p *DeviceProperties) Width() (int, error) {
+	return p.intProperty("resolution_width")
+}
+
+//The resolution_height property as an integer.
+func (p *DeviceProperties) Height() (int, error) {
+	return p.intProperty("resolution_height")
+}
+
+func (p *DeviceProperties) intProperty(name string) (int, error) {
+	value, found := p.get(name)
+	if !found {
+		return 0, fmt.Errorf("%w: %s", ErrCapabilityNotRetained, name)
+	}
+	return parseIntCapability(name, value)
+}
+
+func parseIntCapability(name, value string) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s=%q", ErrCapabilityValue, name, value)
+	}
+	return i, nil
+}
@@ -0,0 +1,54 @@
+package wurflgo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPropertiesOnlyReportParsedCapabilities(t *testing.T) {
+	r := loadTestRepository(t, "display")
+	dev := r.DeviceByID("samsung_gt_i9100_ver1")
+	if value, found := dev.Capability("brand_name"); found {
+		t.Errorf("Capability(brand_name) = %q, true without product_info", value)
+	}
+	if width, err := dev.Int("resolution_width"); err != nil || width != 480 {
+		t.Errorf("Int(resolution_width) = %d, %v", width, err)
+	}
+	if _, err := dev.Properties.Width(); err != nil {
+		t.Errorf("Properties.Width() = %v", err)
+	}
+	if ids := r.QueryIds(Eq("brand_name", "")); len(ids) != 0 {
+		t.Errorf("Eq(brand_name, \"\") matched %v without product_info", ids)
+	}
+
+	r = loadTestRepository(t, "product_info")
+	dev = r.DeviceByID("samsung_gt_i9100_ver1")
+	if _, err := dev.Int("resolution_width"); !errors.Is(err, ErrCapabilityNotRetained) {
+		t.Errorf("Int(resolution_width) = %v, want ErrCapabilityNotRetained", err)
+	}
+	if _, err := dev.Properties.Height(); !errors.Is(err, ErrCapabilityNotRetained) {
+		t.Errorf("Properties.Height() = %v, want ErrCapabilityNotRetained", err)
+	}
+	if brand, found := dev.Capability("brand_name"); !found || brand != "Samsung" {
+		t.Errorf("Capability(brand_name) = %q, %v", brand, found)
+	}
+}
+
+func TestSelectedCapabilitiesAreRetained(t *testing.T) {
+	r := loadTestRepository(t, "display.max_image_width,*.brand_name")
+	dev := r.DeviceByID("samsung_gt_i9100_ver1")
+	if width, found := dev.Capability("max_image_width"); !found || width != "90" {
+		t.Errorf("Capability(max_image_width) = %q, %v", width, found)
+	}
+	if brand, found := dev.Capability("brand_name"); !found || brand != "Samsung" {
+		t.Errorf("Capability(brand_name) = %q, %v", brand, found)
+	}
+	if value, found := dev.Capability("resolution_width"); found {
+		t.Errorf("Capability(resolution_width) = %q, true without selecting it", value)
+	}
+
+	r = loadTestRepository(t, "display.max_image_width", WithRetention(RETAIN_WHITELIST, "brand_name"))
+	if value, found := r.DeviceByID("samsung_gt_i9100_ver1").Capability("max_image_width"); found {
+		t.Errorf("Capability(max_image_width) = %q, true outside the whitelist", value)
+	}
+}
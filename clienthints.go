@@ -0,0 +1,315 @@
+package wurflgo
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+//The high entropy User-Agent Client Hints a server has to ask for with Accept-CH.
+//Sec-CH-UA, Sec-CH-UA-Mobile and Sec-CH-UA-Platform are sent by Chromium without asking.
+var AcceptCHHeaders = []string{
+	"Sec-CH-UA-Model",
+	"Sec-CH-UA-Platform-Version",
+	"Sec-CH-UA-Full-Version-List",
+}
+
+//Asks the browser to send the client hints MatchRequest uses on its following requests.
+func SetAcceptCH(h http.Header) {
+	h.Set("Accept-CH", strings.Join(AcceptCHHeaders, ", "))
+	for _, header := range AcceptCHHeaders {
+		h.Add("Vary", header)
+	}
+}
+
+type Brand struct {
+	Brand   string
+	Version string
+}
+
+//The User-Agent Client Hints sent with a request.
+type ClientHints struct {
+	Brands          []Brand
+	FullVersionList []Brand
+	Mobile          bool
+	Model           string
+	Platform        string
+	PlatformVersion string
+}
+
+var ErrStructuredHeader = errors.New("malformed structured header")
+
+//Parses the Sec-CH-UA headers of a request. Returns nil when the request carries none of them.
+func ParseClientHints(h http.Header) (*ClientHints, error) {
+	if h.Get("Sec-CH-UA") == "" && h.Get("Sec-CH-UA-Platform") == "" && h.Get("Sec-CH-UA-Model") == "" {
+		return nil, nil
+	}
+	ch := new(ClientHints)
+	var err error
+	if ch.Brands, err = parseBrands(h.Get("Sec-CH-UA")); err != nil {
+		return nil, err
+	}
+	if ch.FullVersionList, err = parseBrands(h.Get("Sec-CH-UA-Full-Version-List")); err != nil {
+		return nil, err
+	}
+	if value := h.Get("Sec-CH-UA-Mobile"); value != "" {
+		item, err := parseSFItem(value)
+		if err != nil || !item.isBool {
+			return nil, ErrStructuredHeader
+		}
+		ch.Mobile = item.boolean
+	}
+	for header, field := range map[string]*string{
+		"Sec-CH-UA-Model":            &ch.Model,
+		"Sec-CH-UA-Platform":         &ch.Platform,
+		"Sec-CH-UA-Platform-Version": &ch.PlatformVersion,
+	} {
+		value := h.Get(header)
+		if value == "" {
+			continue
+		}
+		item, err := parseSFItem(value)
+		if err != nil || !item.isString {
+			return nil, ErrStructuredHeader
+		}
+		*field = item.value
+	}
+	return ch, nil
+}
+
+func parseBrands(value string) ([]Brand, error) {
+	if value == "" {
+		return nil, nil
+	}
+	items, err := parseSFList(value)
+	if err != nil {
+		return nil, err
+	}
+	brands := []Brand{}
+	for _, item := range items {
+		if !item.isString {
+			return nil, ErrStructuredHeader
+		}
+		brands = append(brands, Brand{Brand: item.value, Version: item.params["v"]})
+	}
+	return brands, nil
+}
+
+//Brands in the order we prefer them, GREASE brands such as "Not A;Brand" are never picked.
+var preferredBrands = []string{"Google Chrome", "Microsoft Edge", "Opera", "Chromium"}
+
+//Returns the browser brand and the most precise version the hints carry.
+func (ch *ClientHints) Browser() Brand {
+	for _, list := range [][]Brand{ch.FullVersionList, ch.Brands} {
+		for _, preferred := range preferredBrands {
+			for _, brand := range list {
+				if brand.Brand == preferred {
+					return brand
+				}
+			}
+		}
+	}
+	return Brand{}
+}
+
+//The brands whose version is the version of the Chrome token. Opera and Edge send their own version
+//next to the Chromium version they are built on.
+var chromeBrands = []string{"Google Chrome", "Chromium"}
+
+//Returns the full Chromium version the hints carry, or "" when they only carry the major version.
+func (ch *ClientHints) chromeVersion() string {
+	for _, list := range [][]Brand{ch.FullVersionList, ch.Brands} {
+		for _, preferred := range chromeBrands {
+			for _, brand := range list {
+				if brand.Brand == preferred && strings.Count(brand.Version, ".") == 3 {
+					return brand.Version
+				}
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	reducedAndroidRx = regexp.MustCompile(`Android 10; K\)`)
+	reducedChromeRx  = regexp.MustCompile(`Chrome/\d+\.0\.0\.0`)
+	trailingZerosRx  = regexp.MustCompile(`(\.0)+$`)
+)
+
+//Rebuilds the parts of a reduced user agent that the hints carry: the Android version and device model
+//that Chromium replaces with "Android 10; K", and the Chrome version it truncates to "<major>.0.0.0".
+//The frozen "Windows NT 10.0" token is kept as is, WURFL does not tell Windows 10 and 11 apart.
+func (ch *ClientHints) ApplyTo(ua string) string {
+	if ch.Platform == "Android" && ch.Model != "" && reducedAndroidRx.MatchString(ua) {
+		version := trailingZerosRx.ReplaceAllString(ch.PlatformVersion, "")
+		if version == "" {
+			version = "10"
+		}
+		ua = reducedAndroidRx.ReplaceAllLiteralString(ua, "Android "+version+"; "+ch.Model+")")
+	}
+	if version := ch.chromeVersion(); version != "" {
+		ua = reducedChromeRx.ReplaceAllLiteralString(ua, "Chrome/"+version)
+	}
+	return ua
+}
+
+type sfItem struct {
+	value    string
+	isString bool
+	isBool   bool
+	boolean  bool
+	params   map[string]string
+}
+
+//A minimal RFC 8941 parser covering the lists of strings, strings and booleans the Sec-CH-UA headers use.
+type sfParser struct {
+	s   string
+	pos int
+}
+
+func parseSFList(s string) ([]sfItem, error) {
+	p := &sfParser{s: s}
+	items := []sfItem{}
+	p.skipSpaces()
+	for p.pos < len(p.s) {
+		item, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			break
+		}
+		if p.s[p.pos] != ',' {
+			return nil, ErrStructuredHeader
+		}
+		p.pos++
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			return nil, ErrStructuredHeader
+		}
+	}
+	return items, nil
+}
+
+func parseSFItem(s string) (sfItem, error) {
+	p := &sfParser{s: s}
+	p.skipSpaces()
+	item, err := p.item()
+	if err != nil {
+		return item, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return item, ErrStructuredHeader
+	}
+	return item, nil
+}
+
+func (p *sfParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *sfParser) item() (sfItem, error) {
+	item, err := p.bareItem()
+	if err != nil {
+		return item, err
+	}
+	item.params = make(map[string]string)
+	for p.pos < len(p.s) && p.s[p.pos] == ';' {
+		p.pos++
+		p.skipSpaces()
+		key := p.key()
+		if key == "" {
+			return item, ErrStructuredHeader
+		}
+		value := sfItem{isBool: true, boolean: true}
+		if p.pos < len(p.s) && p.s[p.pos] == '=' {
+			p.pos++
+			if value, err = p.bareItem(); err != nil {
+				return item, err
+			}
+		}
+		item.params[key] = value.value
+	}
+	return item, nil
+}
+
+func (p *sfParser) bareItem() (sfItem, error) {
+	if p.pos >= len(p.s) {
+		return sfItem{}, ErrStructuredHeader
+	}
+	switch c := p.s[p.pos]; {
+	case c == '"':
+		return p.string()
+	case c == '?':
+		if p.pos+1 >= len(p.s) || (p.s[p.pos+1] != '0' && p.s[p.pos+1] != '1') {
+			return sfItem{}, ErrStructuredHeader
+		}
+		item := sfItem{isBool: true, boolean: p.s[p.pos+1] == '1', value: p.s[p.pos : p.pos+2]}
+		p.pos += 2
+		return item, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.s) && (p.s[p.pos] == '.' || (p.s[p.pos] >= '0' && p.s[p.pos] <= '9')) {
+			p.pos++
+		}
+		return sfItem{value: p.s[start:p.pos]}, nil
+	case c == '*' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		start := p.pos
+		for p.pos < len(p.s) && isTokenChar(p.s[p.pos]) {
+			p.pos++
+		}
+		return sfItem{value: p.s[start:p.pos]}, nil
+	}
+	return sfItem{}, ErrStructuredHeader
+}
+
+func (p *sfParser) string() (sfItem, error) {
+	var value strings.Builder
+	p.pos++
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '\\':
+			if p.pos >= len(p.s) || (p.s[p.pos] != '"' && p.s[p.pos] != '\\') {
+				return sfItem{}, ErrStructuredHeader
+			}
+			value.WriteByte(p.s[p.pos])
+			p.pos++
+		case c == '"':
+			return sfItem{value: value.String(), isString: true}, nil
+		case c < 0x20 || c > 0x7e:
+			return sfItem{}, ErrStructuredHeader
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return sfItem{}, ErrStructuredHeader
+}
+
+func (p *sfParser) key() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+func isTokenChar(c byte) bool {
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~:/", c) != -1
+}
@@ -0,0 +1,205 @@
+package wurflgo
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseSFList(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		items []sfItem
+		err   bool
+	}{
+		{value: `"Chromium";v="110"`, items: []sfItem{{value: "Chromium", isString: true, params: map[string]string{"v": "110"}}}},
+		{value: ` "A";v="1" ,	"B\"C";v="2.0"  `, items: []sfItem{
+			{value: "A", isString: true, params: map[string]string{"v": "1"}},
+			{value: `B"C`, isString: true, params: map[string]string{"v": "2.0"}},
+		}},
+		{value: `?1, token/x;a;b=?0, -1.5`, items: []sfItem{
+			{value: "?1", isBool: true, boolean: true, params: map[string]string{}},
+			{value: "token/x", params: map[string]string{"a": "", "b": "?0"}},
+			{value: "-1.5", params: map[string]string{}},
+		}},
+		{value: ``, items: []sfItem{}},
+		{value: `"A",`, err: true},
+		{value: `"A" "B"`, err: true},
+		{value: `"A`, err: true},
+		{value: `"A\n"`, err: true},
+		{value: `"A";`, err: true},
+		{value: `?2`, err: true},
+		{value: `"Ä"`, err: true},
+	} {
+		items, err := parseSFList(test.value)
+		if (err != nil) != test.err {
+			t.Errorf("parseSFList(%q) error = %v, want error %v", test.value, err, test.err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(items, test.items) {
+			t.Errorf("parseSFList(%q) = %+v, want %+v", test.value, items, test.items)
+		}
+	}
+}
+
+func TestParseSFItem(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		item  sfItem
+		err   bool
+	}{
+		{value: `"Android"`, item: sfItem{value: "Android", isString: true, params: map[string]string{}}},
+		{value: ` "" `, item: sfItem{value: "", isString: true, params: map[string]string{}}},
+		{value: `?0`, item: sfItem{value: "?0", isBool: true, params: map[string]string{}}},
+		{value: `?1`, item: sfItem{value: "?1", isBool: true, boolean: true, params: map[string]string{}}},
+		{value: `"A", "B"`, err: true},
+		{value: `?`, err: true},
+		{value: ``, err: true},
+	} {
+		item, err := parseSFItem(test.value)
+		if (err != nil) != test.err {
+			t.Errorf("parseSFItem(%q) error = %v, want error %v", test.value, err, test.err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(item, test.item) {
+			t.Errorf("parseSFItem(%q) = %+v, want %+v", test.value, item, test.item)
+		}
+	}
+}
+
+func TestParseClientHints(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		headers map[string]string
+		hints   *ClientHints
+		err     bool
+	}{
+		{name: "none", headers: map[string]string{"User-Agent": testUAs[3]}},
+		{name: "android", headers: map[string]string{
+			"Sec-CH-UA":                   `"Not_A Brand";v="99", "Google Chrome";v="110", "Chromium";v="110"`,
+			"Sec-CH-UA-Full-Version-List": `"Google Chrome";v="110.0.5481.153"`,
+			"Sec-CH-UA-Mobile":            `?1`,
+			"Sec-CH-UA-Model":             `"SM-G991B"`,
+			"Sec-CH-UA-Platform":          `"Android"`,
+			"Sec-CH-UA-Platform-Version":  `"12.0.0"`,
+		}, hints: &ClientHints{
+			Brands:          []Brand{{"Not_A Brand", "99"}, {"Google Chrome", "110"}, {"Chromium", "110"}},
+			FullVersionList: []Brand{{"Google Chrome", "110.0.5481.153"}},
+			Mobile:          true,
+			Model:           "SM-G991B",
+			Platform:        "Android",
+			PlatformVersion: "12.0.0",
+		}},
+		{name: "platform only", headers: map[string]string{"Sec-CH-UA-Platform": `"Windows"`}, hints: &ClientHints{Platform: "Windows"}},
+		{name: "mobile is not a boolean", headers: map[string]string{"Sec-CH-UA": `"Chromium";v="110"`, "Sec-CH-UA-Mobile": `"1"`}, err: true},
+		{name: "model is not a string", headers: map[string]string{"Sec-CH-UA-Model": `?1`}, err: true},
+		{name: "brand is not a string", headers: map[string]string{"Sec-CH-UA": `Chromium;v="110"`}, err: true},
+		{name: "malformed list", headers: map[string]string{"Sec-CH-UA": `"Chromium";v="110",`}, err: true},
+	} {
+		h := make(http.Header)
+		for header, value := range test.headers {
+			h.Set(header, value)
+		}
+		hints, err := ParseClientHints(h)
+		if (err != nil) != test.err {
+			t.Errorf("%s: error = %v, want error %v", test.name, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(hints, test.hints) {
+			t.Errorf("%s: hints = %+v, want %+v", test.name, hints, test.hints)
+		}
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	const (
+		reducedAndroid = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Mobile Safari/537.36"
+		reducedWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36"
+	)
+	for _, test := range []struct {
+		name  string
+		hints ClientHints
+		ua    string
+		want  string
+	}{
+		{
+			name: "chrome on android",
+			hints: ClientHints{
+				FullVersionList: []Brand{{"Not_A Brand", "99.0.0.0"}, {"Google Chrome", "109.0.5414.117"}, {"Chromium", "109.0.5414.117"}},
+				Model:           "SM-G991B",
+				Platform:        "Android",
+				PlatformVersion: "12.0.0",
+			},
+			ua:   reducedAndroid,
+			want: "Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.117 Mobile Safari/537.36",
+		},
+		{
+			name:  "android without platform version",
+			hints: ClientHints{Model: "Pixel 7", Platform: "Android"},
+			ua:    reducedAndroid,
+			want:  "Mozilla/5.0 (Linux; Android 10; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Mobile Safari/537.36",
+		},
+		{
+			name:  "android without model",
+			hints: ClientHints{Platform: "Android", PlatformVersion: "13"},
+			ua:    reducedAndroid,
+			want:  reducedAndroid,
+		},
+		{
+			name: "opera",
+			hints: ClientHints{
+				Brands:          []Brand{{"Opera", "95"}, {"Chromium", "109"}},
+				FullVersionList: []Brand{{"Opera", "95.0.4635.46"}, {"Chromium", "109.0.5414.120"}},
+				Platform:        "Windows",
+			},
+			ua:   reducedWindows,
+			want: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.120 Safari/537.36",
+		},
+		{
+			name: "edge",
+			hints: ClientHints{
+				FullVersionList: []Brand{{"Microsoft Edge", "109.0.1518.78"}, {"Chromium", "109.0.5414.120"}},
+				Platform:        "Windows",
+			},
+			ua:   reducedWindows,
+			want: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.120 Safari/537.36",
+		},
+		{
+			name: "opera without a chromium version",
+			hints: ClientHints{
+				Brands:          []Brand{{"Opera", "95"}, {"Chromium", "109"}},
+				FullVersionList: []Brand{{"Opera", "95.0.4635.46"}},
+			},
+			ua:   reducedWindows,
+			want: reducedWindows,
+		},
+		{
+			name:  "major versions only",
+			hints: ClientHints{Brands: []Brand{{"Google Chrome", "109"}, {"Chromium", "109"}}},
+			ua:    reducedWindows,
+			want:  reducedWindows,
+		},
+	} {
+		if got := test.hints.ApplyTo(test.ua); got != test.want {
+			t.Errorf("%s: ApplyTo = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMatchRequestWithClientHints(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.0.0 Mobile Safari/537.36")
+	req.Header.Set("Sec-CH-UA", `"Chromium";v="110", "Not A(Brand";v="24", "Google Chrome";v="110"`)
+	req.Header.Set("Sec-CH-UA-Mobile", `?1`)
+	req.Header.Set("Sec-CH-UA-Platform", `"Android"`)
+	req.Header.Set("Sec-CH-UA-Platform-Version", `"12.0.0"`)
+	req.Header.Set("Sec-CH-UA-Model", `"SM-G991B"`)
+	dev, header := r.MatchRequest(req)
+	if header != "User-Agent" {
+		t.Errorf("MatchRequest read the user agent from %q", header)
+	}
+	if dev == nil || dev.Id != "samsung_sm_g991b_ver1_suban12" {
+		t.Errorf("MatchRequest = %v, want samsung_sm_g991b_ver1_suban12", dev)
+	}
+}
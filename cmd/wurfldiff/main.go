@@ -0,0 +1,69 @@
+//Compares two versions of the WURFL database.
+//
+//	wurfldiff -groups product_info,display old/wurfl.xml new/wurfl.xml
+//
+//Files ending in .gob are read as caches written by Repository.Save, anything else is parsed as wurfl.xml.
+//Exits with status 1 when the databases differ.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iain17/wurflgo"
+)
+
+func load(path string, groups string) (*wurflgo.Repository, error) {
+	if strings.HasSuffix(path, ".gob") {
+		return wurflgo.ReadCache(path)
+	}
+	repository := wurflgo.NewRepository()
+	repository.SetRetention(wurflgo.RETAIN_ALL)
+	wp, err := wurflgo.NewProcessor(groups, path, repository)
+	if err != nil {
+		return nil, err
+	}
+	if err := wp.Process(); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+func main() {
+	groups := flag.String("groups", "product_info", "comma separated capability groups to compare")
+	asJSON := flag.Bool("json", false, "write the report as JSON")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: wurfldiff [-groups product_info] [-json] <old> <new>")
+		os.Exit(2)
+	}
+	repositories := make([]*wurflgo.Repository, 2)
+	for i, path := range flag.Args() {
+		repository, err := load(path, *groups)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		repositories[i] = repository
+	}
+
+	report := wurflgo.Diff(repositories[0], repositories[1])
+	var err error
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if !report.Empty() {
+		os.Exit(1)
+	}
+}
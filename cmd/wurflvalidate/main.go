@@ -0,0 +1,43 @@
+//Checks the structure of a WURFL database and writes the problems it finds as JSON.
+//
+//	wurflvalidate -patches wurfl_patch.xml wurfl.xml
+//
+//Exits with status 1 when the database has errors, warnings alone do not fail the check.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iain17/wurflgo"
+)
+
+func main() {
+	patches := flag.String("patches", "", "comma separated patch files applied in order")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: wurflvalidate [-patches a.xml,b.xml] <wurfl.xml>")
+		os.Exit(2)
+	}
+	options := []wurflgo.Option{}
+	if *patches != "" {
+		options = append(options, wurflgo.WithPatches(strings.Split(*patches, ",")...))
+	}
+	report, err := wurflgo.Validate(flag.Arg(0), options...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if !report.Valid() {
+		os.Exit(1)
+	}
+}
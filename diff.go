@@ -0,0 +1,143 @@
+package wurflgo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+type ParentChange struct {
+	Id   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type UAChange struct {
+	Id   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type CapabilityChange struct {
+	Id         string `json:"id"`
+	Capability string `json:"capability"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+//The differences between two repositories. Every list is sorted by device id.
+type DiffReport struct {
+	Added             []string           `json:"added"`
+	Removed           []string           `json:"removed"`
+	ParentChanges     []ParentChange     `json:"parent_changes"`
+	UAChanges         []UAChange         `json:"ua_changes"`
+	CapabilityChanges []CapabilityChange `json:"capability_changes"`
+}
+
+//Compares two repositories, typically two versions of the WURFL database.
+//Capabilities are compared through Device.Capability and only when both devices report them, so a capability
+//that just one of the repositories retained is not reported as a change.
+func Diff(older, newer *Repository) *DiffReport {
+	report := &DiffReport{
+		Added:             []string{},
+		Removed:           []string{},
+		ParentChanges:     []ParentChange{},
+		UAChanges:         []UAChange{},
+		CapabilityChanges: []CapabilityChange{},
+	}
+	for _, id := range sortedDeviceIds(older) {
+		if newer.find(id) == nil {
+			report.Removed = append(report.Removed, id)
+		}
+	}
+	for _, id := range sortedDeviceIds(newer) {
+		newDev := newer.find(id)
+		oldDev := older.find(id)
+		if oldDev == nil {
+			report.Added = append(report.Added, id)
+			continue
+		}
+		if oldDev.Parent != newDev.Parent {
+			report.ParentChanges = append(report.ParentChanges, ParentChange{Id: id, From: oldDev.Parent, To: newDev.Parent})
+		}
+		if oldDev.UA != newDev.UA {
+			report.UAChanges = append(report.UAChanges, UAChange{Id: id, From: oldDev.UA, To: newDev.UA})
+		}
+		for _, name := range capabilityNames(oldDev, newDev) {
+			from, fromFound := oldDev.Capability(name)
+			to, toFound := newDev.Capability(name)
+			if fromFound && toFound && from != to {
+				report.CapabilityChanges = append(report.CapabilityChanges, CapabilityChange{Id: id, Capability: name, From: from, To: to})
+			}
+		}
+	}
+	return report
+}
+
+func sortedDeviceIds(r *Repository) []string {
+	ids := make([]string, 0, len(r.devices))
+	for id := range r.devices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func capabilityNames(devices ...*Device) []string {
+	seen := make(map[string]bool)
+	for _, name := range PropertyNames {
+		seen[name] = true
+	}
+	for _, dev := range devices {
+		for name := range dev.Capabilities {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *DiffReport) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.ParentChanges) == 0 &&
+		len(d.UAChanges) == 0 && len(d.CapabilityChanges) == 0
+}
+
+//Writes the report in a human readable form.
+func (d *DiffReport) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("%d added, %d removed, %d reparented, %d user agents changed, %d capabilities changed\n",
+		len(d.Added), len(d.Removed), len(d.ParentChanges), len(d.UAChanges), len(d.CapabilityChanges))
+	for _, id := range d.Added {
+		ew.printf("+ %s\n", id)
+	}
+	for _, id := range d.Removed {
+		ew.printf("- %s\n", id)
+	}
+	for _, c := range d.ParentChanges {
+		ew.printf("~ %s fall_back: %s -> %s\n", c.Id, c.From, c.To)
+	}
+	for _, c := range d.UAChanges {
+		ew.printf("~ %s user_agent: %q -> %q\n", c.Id, c.From, c.To)
+	}
+	for _, c := range d.CapabilityChanges {
+		ew.printf("~ %s %s: %q -> %q\n", c.Id, c.Capability, c.From, c.To)
+	}
+	return ew.err
+}
+
+//Remembers the first write error so a sequence of writes only needs to be checked once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,75 @@
+package wurflgo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	older := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	changed := strings.NewReplacer(
+		`<device id="nokia_6600_sub" user_agent="Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0" fall_back="nokia_generic_series60">`,
+		`<device id="nokia_6600_sub" user_agent="Nokia6600/2.0 (4.09.2) SymbianOS/7.0s Series60/2.0" fall_back="generic_mobile">`,
+		`<capability name="resolution_width" value="480"/>`,
+		`<capability name="resolution_width" value="800"/>`,
+		`<device id="google_chrome"`,
+		`<device id="nokia_7610_ver1" user_agent="Nokia7610/2.0" fall_back="nokia_generic_series60">
+</device>
+<device id="google_chrome"`,
+	).Replace(testDatabase)
+	changed = strings.Replace(changed, `<device id="samsung_sm_g991b_ver1_suban12" user_agent="Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.117 Mobile Safari/537.36" fall_back="samsung_sm_g991b_ver1">
+</device>
+`, "", 1)
+	newer, err := LoadReader(strings.NewReader(changed), "all", WithLogger(discardLogger), WithRetention(RETAIN_ALL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Diff(older, newer)
+	expected := &DiffReport{
+		Added:         []string{"nokia_7610_ver1"},
+		Removed:       []string{"samsung_sm_g991b_ver1_suban12"},
+		ParentChanges: []ParentChange{{Id: "nokia_6600_sub", From: "nokia_generic_series60", To: "generic_mobile"}},
+		UAChanges: []UAChange{{
+			Id:   "nokia_6600_sub",
+			From: "Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0",
+			To:   "Nokia6600/2.0 (4.09.2) SymbianOS/7.0s Series60/2.0",
+		}},
+		CapabilityChanges: []CapabilityChange{
+			//Inherited from the old fall_back.
+			{Id: "nokia_6600_sub", Capability: "brand_name", From: "Nokia", To: ""},
+			{Id: "samsung_gt_i9100_ver1", Capability: "resolution_width", From: "480", To: "800"},
+		},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Diff = %+v, want %+v", report, expected)
+	}
+	if report.Empty() {
+		t.Error("Empty() = true for a report with changes")
+	}
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text.String(), "1 added, 1 removed, 1 reparented, 1 user agents changed, 2 capabilities changed\n") {
+		t.Errorf("WriteText wrote %q", text.String())
+	}
+}
+
+func TestDiffWithDifferentRetention(t *testing.T) {
+	all := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	for _, other := range []*Repository{
+		loadTestRepository(t, "all"),
+		loadTestRepository(t, "product_info"),
+		loadTestRepository(t, "all", WithRetention(RETAIN_WHITELIST, "max_image_width")),
+	} {
+		if report := Diff(all, other); !report.Empty() {
+			t.Errorf("Diff of the same database = %+v", report)
+		}
+		if report := Diff(other, all); !report.Empty() {
+			t.Errorf("Diff of the same database = %+v", report)
+		}
+	}
+}
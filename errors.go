@@ -0,0 +1,92 @@
+package wurflgo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//Returned when the database or cache file does not exist.
+type MissingFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *MissingFileError) Error() string {
+	return fmt.Sprintf("wurflgo: file %s does not exist", e.Path)
+}
+
+func (e *MissingFileError) Unwrap() error {
+	return e.Err
+}
+
+//Returned when the database is not well formed XML.
+type XMLError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *XMLError) Error() string {
+	return fmt.Sprintf("wurflgo: malformed XML in %s at line %d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *XMLError) Unwrap() error {
+	return e.Err
+}
+
+//Returned when a device falls back to a device that is not in the database.
+type OrphanError struct {
+	Id     string
+	Parent string
+}
+
+func (e *OrphanError) Error() string {
+	return fmt.Sprintf("wurflgo: device %s falls back to unknown device %s", e.Id, e.Parent)
+}
+
+//Returned when the fall_back chain of a device leads back to the device itself.
+//Ids lists the devices of the cycle, each one falling back to the next.
+type CycleError struct {
+	Ids []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("wurflgo: devices %s fall back in a cycle", strings.Join(e.Ids, " -> "))
+}
+
+//Returned when a cache file was written by an incompatible version of wurflgo.
+type CacheVersionError struct {
+	Path     string
+	Version  int
+	Expected int
+}
+
+func (e *CacheVersionError) Error() string {
+	return fmt.Sprintf("wurflgo: cache %s has version %d, expected %d", e.Path, e.Version, e.Expected)
+}
+
+var ErrCacheChecksum = errors.New("checksum mismatch")
+
+//Returned when a cache file is truncated or its contents do not match the checksum it ends with.
+type CorruptCacheError struct {
+	Path string
+	Err  error
+}
+
+func (e *CorruptCacheError) Error() string {
+	return fmt.Sprintf("wurflgo: cache %s is corrupt: %v", e.Path, e.Err)
+}
+
+func (e *CorruptCacheError) Unwrap() error {
+	return e.Err
+}
+
+func openFile(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, &MissingFileError{Path: path, Err: err}
+	}
+	return f, err
+}
@@ -0,0 +1,90 @@
+package wurflgo
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMissingFileError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "wurfl.xml")
+	_, loadErr := Load(missing, "product_info", WithLogger(discardLogger))
+	_, cacheErr := ReadCache(missing)
+	database, _ := writeTestDatabase(t)
+	_, patchErr := Load(database, "product_info", WithLogger(discardLogger), WithPatches(missing))
+	for name, err := range map[string]error{"Load": loadErr, "ReadCache": cacheErr, "WithPatches": patchErr} {
+		var missingErr *MissingFileError
+		if !errors.As(err, &missingErr) {
+			t.Errorf("%s: error = %v, want a MissingFileError", name, err)
+			continue
+		}
+		if missingErr.Path != missing {
+			t.Errorf("%s: MissingFileError.Path = %q, want %q", name, missingErr.Path, missing)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: error %v does not unwrap to fs.ErrNotExist", name, err)
+		}
+	}
+	if r := New(missing, "product_info"); r != nil {
+		t.Error("New of a missing file did not return nil")
+	}
+	if r := Read(missing); r != nil {
+		t.Error("Read of a missing file did not return nil")
+	}
+}
+
+func TestXMLError(t *testing.T) {
+	const malformed = `<?xml version="1.0" encoding="UTF-8"?>
+<wurfl>
+<devices>
+<device id="generic" user_agent="" fall_back="root">
+</devic>
+</devices>
+</wurfl>`
+	dir := t.TempDir()
+	database := filepath.Join(dir, "wurfl.xml")
+	patch := filepath.Join(dir, "patch.xml")
+	valid, _ := writeTestDatabase(t)
+	for path, data := range map[string]string{database: malformed, patch: strings.Replace(malformed, "wurfl>", "wurfl_patch>", -1)} {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, databaseErr := Load(database, "product_info", WithLogger(discardLogger))
+	_, patchErr := Load(valid, "product_info", WithLogger(discardLogger), WithPatches(patch))
+	for path, err := range map[string]error{database: databaseErr, patch: patchErr} {
+		var xmlErr *XMLError
+		if !errors.As(err, &xmlErr) {
+			t.Errorf("loading %s: error = %v, want an XMLError", path, err)
+			continue
+		}
+		if xmlErr.Path != path || xmlErr.Line != 5 {
+			t.Errorf("XMLError at %s line %d, want %s line 5", xmlErr.Path, xmlErr.Line, path)
+		}
+		var syntaxErr *xml.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("XMLError %v does not unwrap to the xml.SyntaxError", err)
+		}
+	}
+}
+
+func TestOrphanError(t *testing.T) {
+	orphan := strings.Replace(testDatabase, `fall_back="nokia_generic_series60"`, `fall_back="nokia_generic_series40"`, 1)
+	wp, err := NewReaderProcessor("product_info", strings.NewReader(orphan), "orphan.xml", NewRepository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wp.Logger = discardLogger
+	err = wp.Process()
+	var orphanErr *OrphanError
+	if !errors.As(err, &orphanErr) {
+		t.Fatalf("Process() error = %v, want an OrphanError", err)
+	}
+	if orphanErr.Id != "nokia_6600_sub" || orphanErr.Parent != "nokia_generic_series40" {
+		t.Errorf("OrphanError = %+v, want nokia_6600_sub falling back to nokia_generic_series40", orphanErr)
+	}
+}
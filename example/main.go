@@ -3,15 +3,20 @@ package main
 import (
 	"github.com/iain17/wurflgo"
 	"fmt"
+	"os"
 )
 
 func main() {
-	repository := wurflgo.Read("wurfl.gob")
-	if repository == nil {
-		repository = wurflgo.New("wurfl.xml", "product_info")
-		error := repository.Save("wurfl.gob")
-		if error != nil {
-			fmt.Println(error)
+	repository, err := wurflgo.ReadCache("wurfl.gob")
+	if err != nil {
+		fmt.Println(err)
+		repository, err = wurflgo.Load("wurfl.xml", "product_info")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := repository.Save("wurfl.gob"); err != nil {
+			fmt.Println(err)
 		}
 	}
 	fmt.Println("Finished loading")
@@ -22,4 +27,4 @@ func main() {
 	} else {
 		fmt.Println("Device not found!")
 	}
-}
\ No newline at end of file
+}
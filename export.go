@@ -0,0 +1,82 @@
+package wurflgo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+//The columns ExportCSV writes when none are given.
+var DefaultExportColumns = append([]string{"id", "user_agent", "fall_back", "actual_device_root"}, PropertyNames...)
+
+type exportRecord struct {
+	Id               string            `json:"id"`
+	UA               string            `json:"user_agent"`
+	Parent           string            `json:"fall_back"`
+	ActualDeviceRoot bool              `json:"actual_device_root"`
+	Properties       *DeviceProperties `json:"properties,omitempty"`
+	Capabilities     map[string]string `json:"capabilities,omitempty"`
+}
+
+//Writes every device as one JSON object per line, sorted by device id.
+func (r *Repository) ExportJSONL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	for _, id := range sortedDeviceIds(r) {
+		dev := r.devices[id]
+		err := encoder.Encode(&exportRecord{
+			Id:               dev.Id,
+			UA:               dev.UA,
+			Parent:           dev.Parent,
+			ActualDeviceRoot: dev.ActualDeviceRoot,
+			Properties:       dev.Properties,
+			Capabilities:     dev.Capabilities,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+//Writes every device as a CSV row, sorted by device id, after a header row naming the columns.
+//Besides id, user_agent, fall_back and actual_device_root a column can name any capability or device property.
+//Uses DefaultExportColumns when no columns are given.
+func (r *Repository) ExportCSV(w io.Writer, columns ...string) error {
+	if len(columns) == 0 {
+		columns = DefaultExportColumns
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, id := range sortedDeviceIds(r) {
+		dev := r.devices[id]
+		for i, column := range columns {
+			row[i] = dev.column(column)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (dev *Device) column(name string) string {
+	switch name {
+	case "id":
+		return dev.Id
+	case "user_agent":
+		return dev.UA
+	case "fall_back":
+		return dev.Parent
+	case "actual_device_root":
+		return strconv.FormatBool(dev.ActualDeviceRoot)
+	}
+	value, _ := dev.Capability(name)
+	return value
+}
@@ -0,0 +1,110 @@
+package wurflgo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testDeviceIds = []string{
+	"generic", "generic_android", "generic_mobile", "generic_web_browser", "google_chrome",
+	"nokia_6600_sub", "nokia_generic_series60", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12",
+}
+
+func TestExportJSONL(t *testing.T) {
+	r := loadTestRepository(t, "product_info,display", WithRetention(RETAIN_ALL))
+	var buf bytes.Buffer
+	if err := r.ExportJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	records := make(map[string]exportRecord)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record exportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, record.Id)
+		records[record.Id] = record
+	}
+	if !reflect.DeepEqual(ids, testDeviceIds) {
+		t.Errorf("exported ids = %v, want %v", ids, testDeviceIds)
+	}
+	samsung := records["samsung_gt_i9100_ver1"]
+	if samsung.UA != testUAs[0] || samsung.Parent != "generic_android" || !samsung.ActualDeviceRoot {
+		t.Errorf("exported samsung_gt_i9100_ver1 = %+v", samsung)
+	}
+	if samsung.Properties == nil || samsung.Properties.BrandName != "Samsung" || samsung.Properties.ResolutionWidth != "480" {
+		t.Errorf("exported properties = %+v", samsung.Properties)
+	}
+	for name, want := range map[string]string{"is_wireless_device": "true", "device_os": "Android", "max_image_width": "90"} {
+		if got := samsung.Capabilities[name]; got != want {
+			t.Errorf("exported %s = %q, want %q", name, got, want)
+		}
+	}
+	if generic := records["generic"]; generic.Parent != "" || generic.ActualDeviceRoot {
+		t.Errorf("exported generic = %+v", generic)
+	}
+}
+
+func readCSV(t *testing.T, r *Repository, columns ...string) [][]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.ExportCSV(&buf, columns...); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExportCSV(t *testing.T) {
+	r := loadTestRepository(t, "product_info,display")
+	rows := readCSV(t, r)
+	if len(rows) != len(testDeviceIds)+1 {
+		t.Fatalf("ExportCSV wrote %d rows, want a header and %d devices", len(rows), len(testDeviceIds))
+	}
+	if !reflect.DeepEqual(rows[0], DefaultExportColumns) {
+		t.Errorf("header = %v, want %v", rows[0], DefaultExportColumns)
+	}
+	ids := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		ids = append(ids, row[0])
+	}
+	if !sort.StringsAreSorted(ids) || !reflect.DeepEqual(ids, testDeviceIds) {
+		t.Errorf("exported ids = %v, want %v", ids, testDeviceIds)
+	}
+	//The user agent contains commas and is quoted.
+	want := []string{"samsung_gt_i9100_ver1", testUAs[0], "generic_android", "true", "Samsung", "GT-I9100", "", "", "480", "", "Android", "", "", ""}
+	if samsung := rows[1+sort.SearchStrings(testDeviceIds, "samsung_gt_i9100_ver1")]; !reflect.DeepEqual(samsung, want) {
+		t.Errorf("samsung_gt_i9100_ver1 row = %q, want %q", samsung, want)
+	}
+}
+
+func TestExportCSVColumns(t *testing.T) {
+	r := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	rows := readCSV(t, r, "id", "fall_back", "is_wireless_device", "resolution_width", "no_such_capability")
+	want := [][]string{
+		{"id", "fall_back", "is_wireless_device", "resolution_width", "no_such_capability"},
+		{"generic", "", "false", "90", ""},
+		{"generic_android", "generic_mobile", "true", "90", ""},
+		{"generic_mobile", "generic", "true", "90", ""},
+		{"generic_web_browser", "generic", "false", "1024", ""},
+		{"google_chrome", "generic_web_browser", "false", "1024", ""},
+		{"nokia_6600_sub", "nokia_generic_series60", "true", "90", ""},
+		{"nokia_generic_series60", "generic_mobile", "true", "90", ""},
+		{"samsung_gt_i9100_ver1", "generic_android", "true", "480", ""},
+		{"samsung_sm_g991b_ver1", "generic_android", "true", "1080", ""},
+		{"samsung_sm_g991b_ver1_suban12", "samsung_sm_g991b_ver1", "true", "1080", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("ExportCSV =\n%q\nwant\n%q", rows, want)
+	}
+}
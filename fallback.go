@@ -0,0 +1,164 @@
+package wurflgo
+
+import (
+	"errors"
+	"sort"
+)
+
+//Decides what the processor does with devices whose fall_back can never be registered.
+type FallBackPolicy int
+
+const (
+	//Fail loading with an OrphanError or CycleError for every problem. This is the default.
+	FALLBACK_FAIL FallBackPolicy = iota
+	//Leave the device out of the repository, together with the devices falling back to it.
+	FALLBACK_DROP
+	//Register the device as a child of generic. When the database has no generic device there is nothing to
+	//reparent to and loading fails as with FALLBACK_FAIL.
+	FALLBACK_REPARENT
+)
+
+//Why the fall_back of a device could not be registered.
+type FallBackProblem string
+
+const (
+	//The fall_back is not in the database.
+	PROBLEM_MISSING_PARENT FallBackProblem = "missing_parent"
+	//The fall_back chain leads back to the device.
+	PROBLEM_CYCLE FallBackProblem = "cycle"
+	//The fall_back was dropped by FALLBACK_DROP.
+	PROBLEM_DROPPED_PARENT FallBackProblem = "dropped_parent"
+)
+
+//What happened to an affected device.
+type FallBackAction string
+
+const (
+	ACTION_FAILED     FallBackAction = "failed"
+	ACTION_DROPPED    FallBackAction = "dropped"
+	ACTION_REPARENTED FallBackAction = "reparented"
+)
+
+//A device affected by a broken fall_back. Parent is the fall_back the database gave it and Cycle lists
+//the devices of the cycle, starting with Id, when Problem is PROBLEM_CYCLE.
+type AffectedDevice struct {
+	Id      string          `json:"id"`
+	Parent  string          `json:"parent"`
+	Problem FallBackProblem `json:"problem"`
+	Cycle   []string        `json:"cycle,omitempty"`
+	Action  FallBackAction  `json:"action"`
+}
+
+//Sets the policy for devices whose fall_back is missing or part of a cycle.
+func WithFallBackPolicy(policy FallBackPolicy) Option {
+	return func(wp *WurflProcessor) {
+		wp.FallBackPolicy = policy
+	}
+}
+
+//Returns the devices the fall_back policy dropped or reparented while loading, sorted by id.
+func (r *Repository) AffectedDevices() []AffectedDevice {
+	return r.affected
+}
+
+//Finds the problems that keep the deferred devices from being registered and applies the policy to them.
+//Only the devices causing a problem are handled, their descendants are registered or handled afterwards.
+func (wp *WurflProcessor) resolveFallBacks() error {
+	affected := wp.findFallBackProblems(wp.DeferredDevices)
+	policy := wp.FallBackPolicy
+	if policy == FALLBACK_REPARENT && wp.DeviceList[GENERIC] == nil {
+		policy = FALLBACK_FAIL
+	}
+	errs := []error{}
+	drop := make(map[string]bool)
+	for i := range affected {
+		dev := wp.DeviceList[affected[i].Id]
+		switch policy {
+		case FALLBACK_DROP:
+			affected[i].Action = ACTION_DROPPED
+			drop[dev.Id] = true
+		case FALLBACK_REPARENT:
+			affected[i].Action = ACTION_REPARENTED
+			dev.Parent = GENERIC
+			if dev.Id == GENERIC {
+				dev.Parent = ""
+			}
+		default:
+			affected[i].Action = ACTION_FAILED
+			if affected[i].Problem == PROBLEM_CYCLE {
+				errs = append(errs, &CycleError{Ids: affected[i].Cycle})
+			} else {
+				errs = append(errs, &OrphanError{Id: dev.Id, Parent: dev.Parent})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(drop) > 0 {
+		deferred := []string{}
+		for _, id := range wp.DeferredDevices {
+			if drop[id] {
+				delete(wp.DeviceList, id)
+				wp.dropped[id] = true
+				continue
+			}
+			deferred = append(deferred, id)
+		}
+		wp.DeferredDevices = deferred
+	}
+	wp.Out.affected = append(wp.Out.affected, affected...)
+	sort.SliceStable(wp.Out.affected, func(i, j int) bool {
+		return wp.Out.affected[i].Id < wp.Out.affected[j].Id
+	})
+	wp.Logger.Warn("devices with a broken fall_back chain", "devices", len(affected))
+	return nil
+}
+
+//Follows the fall_back chain of every device until it reaches a missing device or loops.
+//A cycle is reported once, on the smallest id in it, so FALLBACK_REPARENT breaks it at a single device.
+func (wp *WurflProcessor) findFallBackProblems(devices []string) []AffectedDevice {
+	ids := append([]string{}, devices...)
+	sort.Strings(ids)
+	seen := make(map[string]bool)
+	affected := []AffectedDevice{}
+	for _, id := range ids {
+		path := []string{}
+		onPath := make(map[string]int)
+		for cur := id; !seen[cur]; {
+			seen[cur] = true
+			onPath[cur] = len(path)
+			path = append(path, cur)
+			parent := wp.DeviceList[cur].Parent
+			if start, found := onPath[parent]; found {
+				affected = append(affected, newCycle(path[start:]))
+				break
+			}
+			if wp.DeviceList[parent] == nil {
+				problem := PROBLEM_MISSING_PARENT
+				if wp.dropped[parent] {
+					problem = PROBLEM_DROPPED_PARENT
+				}
+				affected = append(affected, AffectedDevice{Id: cur, Parent: parent, Problem: problem})
+				break
+			}
+			cur = parent
+		}
+	}
+	sort.Slice(affected, func(i, j int) bool {
+		return affected[i].Id < affected[j].Id
+	})
+	return affected
+}
+
+//Rotates the cycle so it starts at its smallest id.
+func newCycle(members []string) AffectedDevice {
+	first := 0
+	for i, id := range members {
+		if id < members[first] {
+			first = i
+		}
+	}
+	cycle := append(append([]string{}, members[first:]...), members[:first]...)
+	return AffectedDevice{Id: cycle[0], Parent: cycle[1%len(cycle)], Problem: PROBLEM_CYCLE, Cycle: cycle}
+}
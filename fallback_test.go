@@ -0,0 +1,101 @@
+package wurflgo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//The test database with a device falling back to a missing device, a child of it and a fall_back cycle.
+var brokenFallBacks = strings.Replace(testDatabase, `</devices>`, `<device id="orphan_device" user_agent="Orphan/1.0" fall_back="missing_device">
+</device>
+<device id="orphan_child" user_agent="Orphan/1.1" fall_back="orphan_device">
+</device>
+<device id="cycle_a" user_agent="Cycle/A" fall_back="cycle_b">
+</device>
+<device id="cycle_b" user_agent="Cycle/B" fall_back="cycle_a">
+</device>
+</devices>`, 1)
+
+func loadBrokenFallBacks(database string, policy FallBackPolicy) (*Repository, error) {
+	return LoadReader(strings.NewReader(database), "product_info", WithLogger(discardLogger), WithFallBackPolicy(policy))
+}
+
+func TestFallBackFail(t *testing.T) {
+	r, err := loadBrokenFallBacks(brokenFallBacks, FALLBACK_FAIL)
+	if r != nil || err == nil {
+		t.Fatalf("LoadReader = %v, %v, want an error", r, err)
+	}
+	var orphan *OrphanError
+	if !errors.As(err, &orphan) || orphan.Id != "orphan_device" || orphan.Parent != "missing_device" {
+		t.Errorf("error %v does not hold the OrphanError of orphan_device", err)
+	}
+	var cycle *CycleError
+	if !errors.As(err, &cycle) || !reflect.DeepEqual(cycle.Ids, []string{"cycle_a", "cycle_b"}) {
+		t.Errorf("error %v does not hold the CycleError of cycle_a and cycle_b", err)
+	}
+}
+
+func TestFallBackDrop(t *testing.T) {
+	r, err := loadBrokenFallBacks(brokenFallBacks, FALLBACK_DROP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"orphan_device", "orphan_child", "cycle_a", "cycle_b"} {
+		if r.DeviceByID(id) != nil {
+			t.Errorf("%s was not dropped", id)
+		}
+	}
+	if r.DeviceByID("nokia_6600_sub") == nil {
+		t.Error("a device with a valid fall_back was dropped")
+	}
+	checkAffected(t, r, []AffectedDevice{
+		{Id: "cycle_a", Parent: "cycle_b", Problem: PROBLEM_CYCLE, Cycle: []string{"cycle_a", "cycle_b"}, Action: ACTION_DROPPED},
+		{Id: "cycle_b", Parent: "cycle_a", Problem: PROBLEM_DROPPED_PARENT, Action: ACTION_DROPPED},
+		{Id: "orphan_child", Parent: "orphan_device", Problem: PROBLEM_DROPPED_PARENT, Action: ACTION_DROPPED},
+		{Id: "orphan_device", Parent: "missing_device", Problem: PROBLEM_MISSING_PARENT, Action: ACTION_DROPPED},
+	})
+}
+
+func TestFallBackReparent(t *testing.T) {
+	r, err := loadBrokenFallBacks(brokenFallBacks, FALLBACK_REPARENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, parent := range map[string]string{
+		"orphan_device": GENERIC,
+		"orphan_child":  "orphan_device",
+		"cycle_a":       GENERIC,
+		"cycle_b":       "cycle_a",
+	} {
+		if dev := r.DeviceByID(id); dev == nil || dev.Parent != parent {
+			t.Errorf("%s = %+v, want it to fall back to %s", id, dev, parent)
+		}
+	}
+	//Only the devices causing a problem are reparented, their descendants keep their fall_back.
+	checkAffected(t, r, []AffectedDevice{
+		{Id: "cycle_a", Parent: "cycle_b", Problem: PROBLEM_CYCLE, Cycle: []string{"cycle_a", "cycle_b"}, Action: ACTION_REPARENTED},
+		{Id: "orphan_device", Parent: "missing_device", Problem: PROBLEM_MISSING_PARENT, Action: ACTION_REPARENTED},
+	})
+
+	//Without generic there is nothing to reparent to, so the policy fails like FALLBACK_FAIL.
+	withoutGeneric := strings.Replace(brokenFallBacks, `<device id="generic" `, `<device id="not_generic" `, 1)
+	var orphan *OrphanError
+	if _, err := loadBrokenFallBacks(withoutGeneric, FALLBACK_REPARENT); !errors.As(err, &orphan) {
+		t.Errorf("LoadReader without generic = %v, want an OrphanError", err)
+	}
+}
+
+func checkAffected(t *testing.T, r *Repository, expected []AffectedDevice) {
+	t.Helper()
+	affected := append([]AffectedDevice{}, r.AffectedDevices()...)
+	for i := range affected {
+		if len(affected[i].Cycle) == 0 {
+			affected[i].Cycle = nil
+		}
+	}
+	if !reflect.DeepEqual(affected, expected) {
+		t.Errorf("AffectedDevices() = %+v, want %+v", affected, expected)
+	}
+}
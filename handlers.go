@@ -46,12 +46,22 @@ func (c *Chain) AddHandler(hlr Handlers) *Chain{
 }
 
 func (c *Chain) Filter(ua string, deviceId string) {
-	util.Reset()
 	c.Handlers[0].Filter(ua,deviceId)
 }
 
+//Builds the sorted UA indexes of every handler up front. After Prepare the chain is only read
+//while matching, so Match can be called from several goroutines at once.
+func (c *Chain) Prepare() {
+	for _, h := range c.Handlers {
+		h.GetOrderedUAS()
+		if cah, ok := h.(*CatchAllHandler); ok {
+			cah.getMozilla4OrderedUAS()
+			cah.getMozilla5OrderedUAS()
+		}
+	}
+}
+
 func (c *Chain) Match(ua string) string{
-	util.Reset()
 	return c.Handlers[0].Match(ua)
 }
 
@@ -61,6 +71,8 @@ type AlcatelHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewAlcatelHandler(norm Normalizer) *AlcatelHandler{
@@ -105,15 +117,29 @@ func (h *AlcatelHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *AlcatelHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *AlcatelHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *AlcatelHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *AlcatelHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *AlcatelHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -163,7 +189,7 @@ func (h *AlcatelHandler) ApplyMatch(ua string) string {
 
 func (h *AlcatelHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *AlcatelHandler) ApplyExactMatch(ua string) string{
@@ -189,6 +215,7 @@ func (alh *AlcatelHandler) SetNextHandler(hlr Handlers){
 
 func (h *AlcatelHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -204,6 +231,8 @@ type AndroidHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 	DefaultAndroidVersion string
 	ValidAndroidVersions []string
@@ -323,15 +352,29 @@ func (h *AndroidHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *AndroidHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *AndroidHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *AndroidHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *AndroidHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *AndroidHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -347,7 +390,7 @@ func (ah *AndroidHandler) CanHandle(ua string) bool{
 
 func (h *AndroidHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *AndroidHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -356,6 +399,7 @@ func (h *AndroidHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *AndroidHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -569,6 +613,8 @@ type AppleHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -628,15 +674,29 @@ func (h *AppleHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *AppleHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *AppleHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *AppleHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *AppleHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *AppleHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -645,7 +705,7 @@ func(h *AppleHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *AppleHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 
@@ -705,6 +765,7 @@ func (h *AppleHandler) Match(ua string) string{
 
 func (h *AppleHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -775,6 +836,8 @@ type BenQHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewBenQHandler(norm Normalizer) *BenQHandler{
@@ -837,15 +900,29 @@ func (h *BenQHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *BenQHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *BenQHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *BenQHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *BenQHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *BenQHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -854,7 +931,7 @@ func(h *BenQHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *BenQHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (b *BenQHandler) SetNextHandler(hlr Handlers){
@@ -903,6 +980,7 @@ func (b *BenQHandler) CanHandle(ua string) bool{
 
 func (h *BenQHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -919,6 +997,8 @@ type BlackBerryHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds map[string]string
 }
 
@@ -996,15 +1076,29 @@ func (h *BlackBerryHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *BlackBerryHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *BlackBerryHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *BlackBerryHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *BlackBerryHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *BlackBerryHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1021,7 +1115,7 @@ func (blh *BlackBerryHandler) CanHandle(ua string) bool {
 
 func (h *BlackBerryHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *BlackBerryHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -1054,6 +1148,7 @@ func (blh *BlackBerryHandler) SetNextHandler(hlr Handlers){
 
 func (h *BlackBerryHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -1096,6 +1191,8 @@ type BotCrawlerTranscoderHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	botCrawlerTrancoder []string
 }
 
@@ -1184,15 +1281,29 @@ func (h *BotCrawlerTranscoderHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *BotCrawlerTranscoderHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *BotCrawlerTranscoderHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *BotCrawlerTranscoderHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *BotCrawlerTranscoderHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *BotCrawlerTranscoderHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1218,7 +1329,7 @@ func (bth *BotCrawlerTranscoderHandler) CanHandle(ua string) bool {
 
 func (h *BotCrawlerTranscoderHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *BotCrawlerTranscoderHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -1265,6 +1376,7 @@ func (h *BotCrawlerTranscoderHandler) Match(ua string) string{
 
 func (h *BotCrawlerTranscoderHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -1284,6 +1396,8 @@ type CatchAllHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	MozillaTolerance int
 	Mozilla5 string
 	Mozilla4 string
@@ -1342,15 +1456,29 @@ func (h *CatchAllHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *CatchAllHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *CatchAllHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *CatchAllHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *CatchAllHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *CatchAllHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1369,7 +1497,7 @@ func (h *CatchAllHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *CatchAllHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *CatchAllHandler) ApplyMatch(ua string) string {
@@ -1443,7 +1571,7 @@ func (cah *CatchAllHandler) applyMozillaConclusiveMatch(ua string) string {
 	if cah.isMozilla4(ua){
 		return cah.applyMozilla4ConclusiveMatch(ua)
 	}
-	match := util.LDMatch(cah.GetOrderedUAS(),ua,cah.MozillaTolerance)
+	match := ldMatch(cah.recorder,cah.GetOrderedUAS(),ua,cah.MozillaTolerance)
 	return cah.UASWithDeviceId[match]
 }
 
@@ -1454,7 +1582,7 @@ func (cah *CatchAllHandler) applyMozilla5ConclusiveMatch(ua string) string {
 	}
 	var match string
 	if !util.CheckIfContainsAnyOf(ua,keys){
-		match = util.LDMatch(cah.getMozilla5OrderedUAS(),ua,cah.MozillaTolerance)
+		match = ldMatch(cah.recorder,cah.getMozilla5OrderedUAS(),ua,cah.MozillaTolerance)
 	}
 	if match != ""{
 		return cah.Mozilla5UASWithDeviceId[match]
@@ -1469,7 +1597,7 @@ func (cah *CatchAllHandler) applyMozilla4ConclusiveMatch(ua string) string {
 	}
 	var match string
 	if !util.CheckIfContainsAnyOf(ua,keys){
-		match = util.LDMatch(cah.getMozilla4OrderedUAS(),ua,cah.MozillaTolerance)
+		match = ldMatch(cah.recorder,cah.getMozilla4OrderedUAS(),ua,cah.MozillaTolerance)
 	}
 	if match != ""{
 		return cah.Mozilla4UASWithDeviceId[match]
@@ -1478,6 +1606,7 @@ func (cah *CatchAllHandler) applyMozilla4ConclusiveMatch(ua string) string {
 }
 
 func (cah *CatchAllHandler) Filter(ua string, deviceId string) {
+	cah.filtered++
 	if cah.isMozilla4(ua){
 		cah.Mozilla4UASWithDeviceId[cah.Normalizer.Normalize(ua)] = deviceId
 		cah.Mozilla4OrderedUAS = []string{}
@@ -1505,23 +1634,25 @@ func (cah *CatchAllHandler) isMozilla(ua string) bool{
 }
 
 func (cah *CatchAllHandler) getMozilla4OrderedUAS() []string {
-	if len(cah.Mozilla4OrderedUAS) == 0 {
-		cah.Mozilla4OrderedUAS = []string{}
+	if len(cah.Mozilla4OrderedUAS) == 0 && len(cah.Mozilla4UASWithDeviceId) > 0 {
+		ordered := []string{}
 		for k := range cah.Mozilla4UASWithDeviceId{
-			cah.Mozilla4OrderedUAS = append(cah.Mozilla4OrderedUAS,k)
+			ordered = append(ordered,k)
 		}
-		sort.Strings(cah.Mozilla4OrderedUAS)
+		sort.Strings(ordered)
+		cah.Mozilla4OrderedUAS = ordered
 	}
 	return cah.Mozilla4OrderedUAS
 }
 
 func (cah *CatchAllHandler) getMozilla5OrderedUAS() []string {
-	if len(cah.Mozilla5OrderedUAS) == 0 {
-		cah.Mozilla5OrderedUAS = []string{}
+	if len(cah.Mozilla5OrderedUAS) == 0 && len(cah.Mozilla5UASWithDeviceId) > 0 {
+		ordered := []string{}
 		for k := range cah.Mozilla5UASWithDeviceId{
-			cah.Mozilla5OrderedUAS = append(cah.Mozilla5OrderedUAS,k)
+			ordered = append(ordered,k)
 		}
-		sort.Strings(cah.Mozilla5OrderedUAS)
+		sort.Strings(ordered)
+		cah.Mozilla5OrderedUAS = ordered
 	}
 	return cah.Mozilla5OrderedUAS
 }
@@ -1532,6 +1663,8 @@ type ChromeHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -1579,15 +1712,29 @@ func (h *ChromeHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *ChromeHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *ChromeHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *ChromeHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *ChromeHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *ChromeHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1596,7 +1743,7 @@ func(h *ChromeHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *ChromeHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 
@@ -1639,6 +1786,7 @@ func (h *ChromeHandler) ApplyMatch(ua string) string {
 
 func (h *ChromeHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -1681,6 +1829,8 @@ type DoCoMoHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -1728,15 +1878,29 @@ func (h *DoCoMoHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *DoCoMoHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *DoCoMoHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *DoCoMoHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *DoCoMoHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *DoCoMoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1746,7 +1910,7 @@ func(h *DoCoMoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *DoCoMoHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *DoCoMoHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -1797,6 +1961,7 @@ func (h *DoCoMoHandler) Match(ua string) string{
 
 func (h *DoCoMoHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -1837,6 +2002,8 @@ type FirefoxHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -1895,15 +2062,29 @@ func (h *FirefoxHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *FirefoxHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *FirefoxHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *FirefoxHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *FirefoxHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *FirefoxHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -1913,7 +2094,7 @@ func(h *FirefoxHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *FirefoxHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (fh *FirefoxHandler) CanHandle(ua string) bool{
@@ -1946,6 +2127,7 @@ func (fh *FirefoxHandler) SetNextHandler(hlr Handlers){
 
 func (h *FirefoxHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2014,6 +2196,8 @@ type GrundigHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewGrundigHandler(norm Normalizer) *GrundigHandler{
@@ -2058,15 +2242,29 @@ func (h *GrundigHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *GrundigHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *GrundigHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *GrundigHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *GrundigHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *GrundigHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2087,7 +2285,7 @@ func (h *GrundigHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *GrundigHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (gh *GrundigHandler) SetNextHandler(hlr Handlers){
@@ -2134,6 +2332,7 @@ func (h *GrundigHandler) ApplyMatch(ua string) string {
 
 func (h *GrundigHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2156,6 +2355,8 @@ type HTCHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 func NewHTCHandler(norm Normalizer) *HTCHandler{
 	hh := new(HTCHandler)
@@ -2199,15 +2400,29 @@ func (h *HTCHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *HTCHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *HTCHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *HTCHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *HTCHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *HTCHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2228,7 +2443,7 @@ func (h *HTCHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *HTCHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *HTCHandler) ApplyExactMatch(ua string) string{
@@ -2264,6 +2479,7 @@ func (hh *HTCHandler) SetNextHandler(hlr Handlers){
 }
 func (h *HTCHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2296,6 +2512,8 @@ type HTCMacHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -2342,15 +2560,29 @@ func (h *HTCMacHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *HTCMacHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *HTCMacHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *HTCMacHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *HTCMacHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *HTCMacHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2365,7 +2597,7 @@ func (h *HTCMacHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *HTCMacHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *HTCMacHandler) ApplyExactMatch(ua string) string{
@@ -2411,6 +2643,7 @@ func (h *HTCMacHandler) ApplyMatch(ua string) string {
 
 func (h *HTCMacHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2454,6 +2687,8 @@ type JavaMidletHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -2503,15 +2738,29 @@ func (h *JavaMidletHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *JavaMidletHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *JavaMidletHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *JavaMidletHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *JavaMidletHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *JavaMidletHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2521,7 +2770,7 @@ func(h *JavaMidletHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *JavaMidletHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *JavaMidletHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -2530,6 +2779,7 @@ func (h *JavaMidletHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *JavaMidletHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2595,6 +2845,8 @@ type KDDIHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -2641,15 +2893,29 @@ func (h *KDDIHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *KDDIHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *KDDIHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *KDDIHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *KDDIHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *KDDIHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2658,7 +2924,7 @@ func(h *KDDIHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *KDDIHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 
@@ -2701,6 +2967,7 @@ func (h *KDDIHandler) ApplyMatch(ua string) string {
 
 func (h *KDDIHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2747,6 +3014,8 @@ type KindleHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -2797,15 +3066,29 @@ func (h *KindleHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *KindleHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *KindleHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *KindleHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *KindleHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *KindleHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2816,7 +3099,7 @@ func(h *KindleHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *KindleHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *KindleHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -2835,6 +3118,7 @@ func (h *KindleHandler) ApplyExactMatch(ua string) string{
 
 func (h *KindleHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -2925,6 +3209,8 @@ type KonquerorHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewKonquerorHandler(norm Normalizer) *KonquerorHandler{
@@ -2969,15 +3255,29 @@ func (h *KonquerorHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *KonquerorHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *KonquerorHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *KonquerorHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *KonquerorHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *KonquerorHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -2994,7 +3294,7 @@ func (h *KonquerorHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *KonquerorHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *KonquerorHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3013,6 +3313,7 @@ func (h *KonquerorHandler) ApplyExactMatch(ua string) string{
 
 func (h *KonquerorHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3067,6 +3368,8 @@ type KyoceraHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewKyoceraHandler(norm Normalizer) *KyoceraHandler{
@@ -3111,15 +3414,29 @@ func (h *KyoceraHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *KyoceraHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *KyoceraHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *KyoceraHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *KyoceraHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *KyoceraHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3136,7 +3453,7 @@ func (h *KyoceraHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *KyoceraHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *KyoceraHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3145,6 +3462,7 @@ func (h *KyoceraHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *KyoceraHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3208,6 +3526,8 @@ type LGHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewLGHandler(norm Normalizer) *LGHandler{
@@ -3250,15 +3570,29 @@ func (h *LGHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *LGHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *LGHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *LGHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *LGHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *LGHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3269,7 +3603,7 @@ func(h *LGHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *LGHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *LGHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3320,6 +3654,7 @@ func (h *LGHandler) Match(ua string) string{
 
 func (h *LGHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3351,6 +3686,8 @@ type LGPLUSHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 	lgPluses map[string][]string
 }
@@ -3418,15 +3755,29 @@ func (h *LGPLUSHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *LGPLUSHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *LGPLUSHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *LGPLUSHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *LGPLUSHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *LGPLUSHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3437,7 +3788,7 @@ func(h *LGPLUSHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *LGPLUSHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *LGPLUSHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3473,6 +3824,7 @@ func (h *LGPLUSHandler) ApplyMatch(ua string) string {
 
 func (h *LGPLUSHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3522,6 +3874,8 @@ type MSIEHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -3578,15 +3932,29 @@ func (h *MSIEHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *MSIEHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *MSIEHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *MSIEHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *MSIEHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *MSIEHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3597,7 +3965,7 @@ func(h *MSIEHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *MSIEHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *MSIEHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3644,6 +4012,7 @@ func (h *MSIEHandler) ApplyMatch(ua string) string {
 
 func (h *MSIEHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3699,6 +4068,8 @@ type MitsubishiHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewMitsubishiHandler(norm Normalizer) *MitsubishiHandler{
@@ -3743,15 +4114,29 @@ func (h *MitsubishiHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *MitsubishiHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *MitsubishiHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *MitsubishiHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *MitsubishiHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *MitsubishiHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3761,7 +4146,7 @@ func(h *MitsubishiHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *MitsubishiHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *MitsubishiHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3780,6 +4165,7 @@ func (h *MitsubishiHandler) ApplyExactMatch(ua string) string{
 
 func (h *MitsubishiHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3839,6 +4225,8 @@ type MotorolaHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -3885,15 +4273,29 @@ func (h *MotorolaHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *MotorolaHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *MotorolaHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *MotorolaHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *MotorolaHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *MotorolaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -3904,7 +4306,7 @@ func(h *MotorolaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *MotorolaHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *MotorolaHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -3955,6 +4357,7 @@ func (h *MotorolaHandler) SetNextHandler(hlr Handlers){
 
 func (h *MotorolaHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -3991,6 +4394,8 @@ type NecHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	NecKgtTolerance int
 }
 
@@ -4038,15 +4443,29 @@ func (h *NecHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *NecHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *NecHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *NecHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *NecHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *NecHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4056,7 +4475,7 @@ func(h *NecHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *NecHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *NecHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -4107,6 +4526,7 @@ func (h *NecHandler) Match(ua string) string{
 
 func (h *NecHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4138,6 +4558,8 @@ type NintendoHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -4185,15 +4607,29 @@ func (h *NintendoHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *NintendoHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *NintendoHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *NintendoHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *NintendoHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *NintendoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4203,7 +4639,7 @@ func(h *NintendoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *NintendoHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *NintendoHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -4254,6 +4690,7 @@ func (h *NintendoHandler) SetNextHandler(hlr Handlers){
 
 func (h *NintendoHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4297,6 +4734,8 @@ type NokiaHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -4344,15 +4783,29 @@ func (h *NokiaHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *NokiaHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *NokiaHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *NokiaHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *NokiaHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *NokiaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4369,7 +4822,7 @@ func (h *NokiaHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *NokiaHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *NokiaHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -4420,6 +4873,7 @@ func (h *NokiaHandler) SetNextHandler(hlr Handlers){
 
 func (h *NokiaHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4456,6 +4910,8 @@ type NokiaOviBrowserHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -4504,15 +4960,29 @@ func (h *NokiaOviBrowserHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *NokiaOviBrowserHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *NokiaOviBrowserHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *NokiaOviBrowserHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *NokiaOviBrowserHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *NokiaOviBrowserHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4526,7 +4996,7 @@ func (h *NokiaOviBrowserHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *NokiaOviBrowserHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *NokiaOviBrowserHandler) ApplyExactMatch(ua string) string{
@@ -4569,6 +5039,7 @@ func (h *NokiaOviBrowserHandler) Match(ua string) string{
 
 func (h *NokiaOviBrowserHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4604,6 +5075,8 @@ type OperaHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -4655,15 +5128,29 @@ func (h *OperaHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *OperaHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *OperaHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *OperaHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *OperaHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *OperaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4673,7 +5160,7 @@ func(h *OperaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *OperaHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *OperaHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -4724,6 +5211,7 @@ func (h *OperaHandler) SetNextHandler(hlr Handlers){
 
 func (h *OperaHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4780,6 +5268,8 @@ type OperaMiniHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	operaMinis map[string]string
 }
 
@@ -4829,15 +5319,29 @@ func (h *OperaMiniHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *OperaMiniHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *OperaMiniHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *OperaMiniHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *OperaMiniHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *OperaMiniHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -4854,7 +5358,7 @@ func (h *OperaMiniHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *OperaMiniHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *OperaMiniHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -4905,6 +5409,7 @@ func (h *OperaMiniHandler) SetNextHandler(hlr Handlers){
 
 func (h *OperaMiniHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -4936,6 +5441,8 @@ type PanasonicHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewPanasonicHandler(norm Normalizer) *PanasonicHandler {
@@ -4980,15 +5487,29 @@ func (h *PanasonicHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *PanasonicHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *PanasonicHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *PanasonicHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *PanasonicHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *PanasonicHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5009,7 +5530,7 @@ func (h *PanasonicHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *PanasonicHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *PanasonicHandler) ApplyExactMatch(ua string) string{
@@ -5046,6 +5567,7 @@ func (h *PanasonicHandler) ApplyMatch(ua string) string {
 
 func (h *PanasonicHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5078,6 +5600,8 @@ type PantechHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	PantechTolerance int
 }
 
@@ -5124,15 +5648,29 @@ func (h *PantechHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *PantechHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *PantechHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *PantechHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *PantechHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *PantechHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5142,7 +5680,7 @@ func(h *PantechHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *PantechHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *PantechHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -5197,6 +5735,7 @@ func (h *PantechHandler) ApplyExactMatch(ua string) string{
 
 func (h *PantechHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5227,6 +5766,8 @@ type PhilipsHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewPhilipsHandler(norm Normalizer) *PhilipsHandler{
@@ -5271,15 +5812,29 @@ func (h *PhilipsHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *PhilipsHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *PhilipsHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *PhilipsHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *PhilipsHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *PhilipsHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5296,7 +5851,7 @@ func (h *PhilipsHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *PhilipsHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *PhilipsHandler) Match(ua string) string{
@@ -5347,6 +5902,7 @@ func (h *PhilipsHandler) SetNextHandler(hlr Handlers){
 
 func (h *PhilipsHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5369,6 +5925,8 @@ type PortalmmmHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewPortalmmmHandler(norm Normalizer) *PortalmmmHandler{
@@ -5413,15 +5971,29 @@ func (h *PortalmmmHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *PortalmmmHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *PortalmmmHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *PortalmmmHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *PortalmmmHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *PortalmmmHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5431,7 +6003,7 @@ func(h *PortalmmmHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *PortalmmmHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *PortalmmmHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -5482,6 +6054,7 @@ func (h *PortalmmmHandler) SetNextHandler(hlr Handlers){
 
 func (h *PortalmmmHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5508,6 +6081,8 @@ type  QtekHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewQtekHandler(norm Normalizer) *QtekHandler {
@@ -5552,15 +6127,29 @@ func (h *QtekHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *QtekHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *QtekHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *QtekHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *QtekHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *QtekHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5577,7 +6166,7 @@ func (h *QtekHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *QtekHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *QtekHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -5628,6 +6217,7 @@ func (h *QtekHandler) SetNextHandler(hlr Handlers){
 
 func (h *QtekHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5651,6 +6241,8 @@ type ReksioHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -5699,15 +6291,29 @@ func (h *ReksioHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *ReksioHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *ReksioHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *ReksioHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *ReksioHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *ReksioHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5717,7 +6323,7 @@ func(h *ReksioHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *ReksioHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *ReksioHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -5764,6 +6370,7 @@ func (h *ReksioHandler) ApplyMatch(ua string) string {
 
 func (h *ReksioHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5795,6 +6402,8 @@ type SPVHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSPVHandler(norm Normalizer) *SPVHandler {
@@ -5839,15 +6448,29 @@ func (h *SPVHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SPVHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SPVHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SPVHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SPVHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SPVHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -5857,7 +6480,7 @@ func(h *SPVHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *SPVHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SPVHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -5876,6 +6499,7 @@ func (h *SPVHandler) ApplyExactMatch(ua string) string{
 
 func (h *SPVHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -5935,6 +6559,8 @@ type SafariHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSafariHandler(norm Normalizer) *SafariHandler{
@@ -5979,15 +6605,29 @@ func (h *SafariHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SafariHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SafariHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SafariHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SafariHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SafariHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6018,7 +6658,7 @@ func (h *SafariHandler) ApplyExactMatch(ua string) string{
 
 func (h *SafariHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SafariHandler) SetNextHandler(hlr Handlers){
@@ -6055,6 +6695,7 @@ func (h *SafariHandler) Match(ua string) string{
 
 func (h *SafariHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6077,6 +6718,8 @@ type SagemHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSagemHandler(norm Normalizer) *SagemHandler {
@@ -6121,15 +6764,29 @@ func (h *SagemHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SagemHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SagemHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SagemHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SagemHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SagemHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6146,7 +6803,7 @@ func (h *SagemHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *SagemHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SagemHandler) ApplyExactMatch(ua string) string{
@@ -6197,6 +6854,7 @@ func (h *SagemHandler) SetNextHandler(hlr Handlers){
 
 func (h *SagemHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6219,6 +6877,8 @@ type SamsungHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSamsungHandler(norm Normalizer) *SamsungHandler {
@@ -6262,16 +6922,30 @@ func (h *SamsungHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SamsungHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SamsungHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SamsungHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SamsungHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
 	//fmt.Println(h.UASWithDeviceId)
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SamsungHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	//fmt.Println("Match here:",match)
 	if match != ""{
 		return h.UASWithDeviceId[match]
@@ -6295,7 +6969,7 @@ func (h *SamsungHandler) ApplyExactMatch(ua string) string{
 
 func (h *SamsungHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SamsungHandler) ApplyMatch(ua string) string {
@@ -6333,6 +7007,7 @@ func (h *SamsungHandler) Match(ua string) string{
 
 func (h *SamsungHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6385,6 +7060,8 @@ type SanyoHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSanyoHandler(norm Normalizer) *SanyoHandler{
@@ -6429,15 +7106,29 @@ func (h *SanyoHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SanyoHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SanyoHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SanyoHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SanyoHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SanyoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6447,7 +7138,7 @@ func(h *SanyoHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *SanyoHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SanyoHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -6498,6 +7189,7 @@ func (h *SanyoHandler) SetNextHandler(hlr Handlers){
 
 func (h *SanyoHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6531,6 +7223,8 @@ type SharpHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSharpHandler(norm Normalizer) *SharpHandler{
@@ -6575,15 +7269,29 @@ func (h *SharpHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SharpHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SharpHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SharpHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SharpHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SharpHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6600,7 +7308,7 @@ func (h *SharpHandler) ApplyConclusiveMatch(ua string) string{
 
 func (h *SharpHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SharpHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -6641,6 +7349,7 @@ func (h *SharpHandler) ApplyMatch(ua string) string {
 
 func (h *SharpHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6674,6 +7383,8 @@ type SiemensHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }	
 
 func NewSiemensHandler(norm Normalizer) *SiemensHandler{
@@ -6718,15 +7429,29 @@ func (h *SiemensHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SiemensHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SiemensHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SiemensHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SiemensHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SiemensHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6735,7 +7460,7 @@ func(h *SiemensHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *SiemensHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SiemensHandler) ApplyConclusiveMatch(ua string) string{
@@ -6794,6 +7519,7 @@ func (h *SiemensHandler) Match(ua string) string{
 
 func (h *SiemensHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6816,6 +7542,8 @@ type SmartTVHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -6864,15 +7592,29 @@ func (h *SmartTVHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SmartTVHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SmartTVHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SmartTVHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SmartTVHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SmartTVHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -6882,7 +7624,7 @@ func(h *SmartTVHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *SmartTVHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SmartTVHandler) ApplyExactMatch(ua string) string{
@@ -6933,6 +7675,7 @@ func (h *SmartTVHandler) Match(ua string) string{
 
 func (h *SmartTVHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -6973,6 +7716,8 @@ type SonyEricssonHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewSonyEricssonHandler(norm Normalizer) *SonyEricssonHandler{
@@ -7017,15 +7762,29 @@ func (h *SonyEricssonHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *SonyEricssonHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *SonyEricssonHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *SonyEricssonHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *SonyEricssonHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *SonyEricssonHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7034,7 +7793,7 @@ func(h *SonyEricssonHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
 
 func (h *SonyEricssonHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *SonyEricssonHandler) IsBlankOrGeneric(deviceId string) bool{
@@ -7053,6 +7812,7 @@ func (h *SonyEricssonHandler) ApplyExactMatch(ua string) string{
 
 func (h *SonyEricssonHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -7118,6 +7878,8 @@ type ToshibaHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewToshibaHandler(norm Normalizer) *ToshibaHandler{
@@ -7162,15 +7924,29 @@ func (h *ToshibaHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *ToshibaHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *ToshibaHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *ToshibaHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *ToshibaHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *ToshibaHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7191,7 +7967,7 @@ func (h *ToshibaHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *ToshibaHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *ToshibaHandler) ApplyExactMatch(ua string) string{
@@ -7238,6 +8014,7 @@ func (h *ToshibaHandler) SetNextHandler(hlr Handlers){
 
 func (h *ToshibaHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -7260,6 +8037,8 @@ type VodafoneHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 }
 
 func NewVodafoneHandler(norm Normalizer) *VodafoneHandler{
@@ -7304,15 +8083,29 @@ func (h *VodafoneHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *VodafoneHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *VodafoneHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *VodafoneHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *VodafoneHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *VodafoneHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7325,7 +8118,7 @@ func (h *VodafoneHandler) IsBlankOrGeneric(deviceId string) bool{
 
 func (h *VodafoneHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *VodafoneHandler) ApplyExactMatch(ua string) string{
@@ -7372,6 +8165,7 @@ func (h *VodafoneHandler) SetNextHandler(hlr Handlers){
 
 func (h *VodafoneHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -7401,6 +8195,8 @@ type WebOSHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -7447,15 +8243,29 @@ func (h *WebOSHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *WebOSHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *WebOSHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *WebOSHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *WebOSHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *WebOSHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7478,7 +8288,7 @@ func (h *WebOSHandler) ApplyExactMatch(ua string) string{
 
 func (h *WebOSHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *WebOSHandler) ApplyMatch(ua string) string {
@@ -7515,6 +8325,7 @@ func (h *WebOSHandler) Match(ua string) string{
 
 func (h *WebOSHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -7572,6 +8383,8 @@ type WindowsPhoneDesktopHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -7619,15 +8432,29 @@ func (h *WindowsPhoneDesktopHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *WindowsPhoneDesktopHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *WindowsPhoneDesktopHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *WindowsPhoneDesktopHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *WindowsPhoneDesktopHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *WindowsPhoneDesktopHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7636,7 +8463,7 @@ func(h *WindowsPhoneDesktopHandler) GetDeviceIdFromLD(ua string, tolerance int)
 
 func (h *WindowsPhoneDesktopHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *WindowsPhoneDesktopHandler) ApplyExactMatch(ua string) string{
@@ -7687,6 +8514,7 @@ func (h *WindowsPhoneDesktopHandler) SetNextHandler(hlr Handlers){
 
 func (h *WindowsPhoneDesktopHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
@@ -7717,6 +8545,8 @@ type WindowsPhoneHandler struct{
 	Normalizer Normalizer
 	UASWithDeviceId map[string]string
 	nextHandler Handlers
+	recorder *matchRecorder
+	filtered int
 	ConstantIds []string
 }
 
@@ -7764,15 +8594,29 @@ func (h *WindowsPhoneHandler) GetOrderedUAS() []string{
 	return h.OrderedUAS
 }
 
+func (h *WindowsPhoneHandler) GetNormalizer() Normalizer{
+	return h.Normalizer
+}
+
+func (h *WindowsPhoneHandler) traced(recorder *matchRecorder) Handlers{
+	clone := *h
+	clone.recorder = recorder
+	return &clone
+}
+
+func (h *WindowsPhoneHandler) filteredDevices() int{
+	return h.filtered
+}
+
 func(h *WindowsPhoneHandler) GetDeviceIdFromRIS(ua string, tolerance int) string{
-	match := util.RISMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := risMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
 	return NO_MATCH
 }
 func(h *WindowsPhoneHandler) GetDeviceIdFromLD(ua string, tolerance int) string{
-	match := util.LDMatch(h.GetOrderedUAS(),ua, tolerance)
+	match := ldMatch(h.recorder,h.GetOrderedUAS(),ua, tolerance)
 	if match != ""{
 		return h.UASWithDeviceId[match]
 	}
@@ -7796,7 +8640,7 @@ func (h *WindowsPhoneHandler) Match(ua string) string{
 
 func (h *WindowsPhoneHandler) LookForMatchingUA(ua string) string{
 	tolerance := util.FirstSlash(ua)
-	return util.RISMatch(h.GetOrderedUAS(),ua,tolerance)
+	return risMatch(h.recorder,h.GetOrderedUAS(),ua,tolerance)
 }
 
 func (h *WindowsPhoneHandler) ApplyExactMatch(ua string) string{
@@ -7829,6 +8673,7 @@ func (h *WindowsPhoneHandler) ApplyMatch(ua string) string {
 
 func (h *WindowsPhoneHandler) Filter(ua string, deviceId string){
 	if h.CanHandle(ua){
+		h.filtered++
 		h.UASWithDeviceId[h.Normalizer.Normalize(ua)] = deviceId
 		h.OrderedUAS = []string{}
 		return
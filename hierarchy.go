@@ -0,0 +1,95 @@
+package wurflgo
+
+import (
+	"errors"
+	"sort"
+)
+
+//Returned by a WalkFunc to skip the descendants of the device it was called with.
+var SkipDevice = errors.New("skip this device")
+
+//Called by Walk for every device with its depth below the device the walk started at.
+type WalkFunc func(dev *Device, depth int) error
+
+//Returns the device with the given id or nil.
+func (r *Repository) DeviceByID(id string) *Device {
+	return r.find(id)
+}
+
+//Returns the fall_back chain of a device, starting with its parent and ending at the root device.
+func (r *Repository) Ancestors(id string) []*Device {
+	ancestors := []*Device{}
+	dev := r.find(id)
+	if dev == nil {
+		return ancestors
+	}
+	for parent := r.find(dev.Parent); parent != nil && len(ancestors) < len(r.devices); parent = r.find(parent.Parent) {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+//Returns every device that falls back to the given device, in the order Walk visits them.
+func (r *Repository) Descendants(id string) []*Device {
+	descendants := []*Device{}
+	r.Walk(id, func(dev *Device, depth int) error {
+		if depth > 0 {
+			descendants = append(descendants, dev)
+		}
+		return nil
+	})
+	return descendants
+}
+
+//Returns the nearest device marked as actual_device_root, starting at the device itself and walking up its fall_back chain.
+//Returns nil when neither the device nor any of its ancestors is an actual device root.
+func (r *Repository) ActualDeviceRoot(id string) *Device {
+	dev := r.find(id)
+	if dev == nil {
+		return nil
+	}
+	if dev.ActualDeviceRoot {
+		return dev
+	}
+	for _, ancestor := range r.Ancestors(id) {
+		if ancestor.ActualDeviceRoot {
+			return ancestor
+		}
+	}
+	return nil
+}
+
+//Walks the device tree depth first starting at the given device, visiting children in order of their id.
+//Returning SkipDevice from fn skips the children of that device, any other error stops the walk and is returned.
+func (r *Repository) Walk(id string, fn WalkFunc) error {
+	dev := r.find(id)
+	if dev == nil {
+		return nil
+	}
+	err := r.walk(dev, 0, fn)
+	if err == SkipDevice {
+		return nil
+	}
+	return err
+}
+
+func (r *Repository) walk(dev *Device, depth int, fn WalkFunc) error {
+	if err := fn(dev, depth); err != nil {
+		return err
+	}
+	children := make([]string, 0, len(dev.Children))
+	for child := range dev.Children {
+		children = append(children, child)
+	}
+	sort.Strings(children)
+	for _, child := range children {
+		childDevice := r.find(child)
+		if childDevice == nil {
+			continue
+		}
+		if err := r.walk(childDevice, depth+1, fn); err != nil && err != SkipDevice {
+			return err
+		}
+	}
+	return nil
+}
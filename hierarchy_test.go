@@ -0,0 +1,106 @@
+package wurflgo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func deviceIds(devices []*Device) []string {
+	ids := make([]string, len(devices))
+	for i, dev := range devices {
+		ids[i] = dev.Id
+	}
+	return ids
+}
+
+func TestAncestorsAndDescendants(t *testing.T) {
+	r := loadTestRepository(t, "product_info")
+	for _, test := range []struct {
+		id          string
+		ancestors   []string
+		descendants []string
+	}{
+		{id: "samsung_sm_g991b_ver1_suban12", ancestors: []string{"samsung_sm_g991b_ver1", "generic_android", "generic_mobile", "generic"}, descendants: []string{}},
+		{id: "generic_mobile", ancestors: []string{"generic"}, descendants: []string{
+			"generic_android", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12",
+			"nokia_generic_series60", "nokia_6600_sub",
+		}},
+		{id: "generic_web_browser", ancestors: []string{"generic"}, descendants: []string{"google_chrome"}},
+		{id: "generic", ancestors: []string{}, descendants: []string{
+			"generic_mobile", "generic_android", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12",
+			"nokia_generic_series60", "nokia_6600_sub", "generic_web_browser", "google_chrome",
+		}},
+		{id: "no_such_device", ancestors: []string{}, descendants: []string{}},
+	} {
+		if ancestors := deviceIds(r.Ancestors(test.id)); !reflect.DeepEqual(ancestors, test.ancestors) {
+			t.Errorf("Ancestors(%q) = %v, want %v", test.id, ancestors, test.ancestors)
+		}
+		if descendants := deviceIds(r.Descendants(test.id)); !reflect.DeepEqual(descendants, test.descendants) {
+			t.Errorf("Descendants(%q) = %v, want %v", test.id, descendants, test.descendants)
+		}
+	}
+}
+
+func TestActualDeviceRoot(t *testing.T) {
+	r := loadTestRepository(t, "product_info")
+	for id, want := range map[string]string{
+		"samsung_sm_g991b_ver1_suban12": "samsung_sm_g991b_ver1",
+		"samsung_sm_g991b_ver1":         "samsung_sm_g991b_ver1",
+		"samsung_gt_i9100_ver1":         "samsung_gt_i9100_ver1",
+		"nokia_6600_sub":                "",
+		"generic":                       "",
+		"no_such_device":                "",
+	} {
+		root := r.ActualDeviceRoot(id)
+		if got := deviceId(root); got != want {
+			t.Errorf("ActualDeviceRoot(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+var errStopWalk = errors.New("stop walking")
+
+func TestWalk(t *testing.T) {
+	r := loadTestRepository(t, "product_info")
+	var visited []string
+	visit := func(skip, stop string) WalkFunc {
+		visited = []string{}
+		return func(dev *Device, depth int) error {
+			visited = append(visited, fmt.Sprintf("%d %s", depth, dev.Id))
+			switch dev.Id {
+			case skip:
+				return SkipDevice
+			case stop:
+				return errStopWalk
+			}
+			return nil
+		}
+	}
+
+	if err := r.Walk("generic_mobile", visit("generic_android", "")); err != nil {
+		t.Errorf("Walk returned %v", err)
+	}
+	if want := []string{"0 generic_mobile", "1 generic_android", "1 nokia_generic_series60", "2 nokia_6600_sub"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk skipping generic_android visited %v, want %v", visited, want)
+	}
+
+	if err := r.Walk("generic_android", visit("generic_android", "")); err != nil {
+		t.Errorf("Walk skipping its first device returned %v", err)
+	}
+	if want := []string{"0 generic_android"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk skipping its first device visited %v, want %v", visited, want)
+	}
+
+	if err := r.Walk("generic", visit("", "samsung_gt_i9100_ver1")); !errors.Is(err, errStopWalk) {
+		t.Errorf("Walk returned %v, want the error of the WalkFunc", err)
+	}
+	if want := []string{"0 generic", "1 generic_mobile", "2 generic_android", "3 samsung_gt_i9100_ver1"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("stopped Walk visited %v, want %v", visited, want)
+	}
+
+	if err := r.Walk("no_such_device", visit("", "")); err != nil || len(visited) != 0 {
+		t.Errorf("Walk of an unknown device = %v and visited %v", err, visited)
+	}
+}
@@ -0,0 +1,98 @@
+package wurflgo
+
+import (
+	"container/list"
+	"sync"
+)
+
+type MatchCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+//A size bounded least recently used cache of match results, keyed on the raw user agent.
+type matchCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	stats    MatchCacheStats
+}
+
+type matchCacheEntry struct {
+	ua     string
+	device *Device
+}
+
+func newMatchCache(capacity int) *matchCache {
+	return &matchCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *matchCache) get(ua string) (*Device, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, found := c.entries[ua]
+	if !found {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*matchCacheEntry).device, true
+}
+
+func (c *matchCache) add(ua string, device *Device) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, found := c.entries[ua]; found {
+		element.Value.(*matchCacheEntry).device = device
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[ua] = c.order.PushFront(&matchCacheEntry{ua: ua, device: device})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*matchCacheEntry).ua)
+		c.stats.Evictions++
+	}
+}
+
+func (c *matchCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *matchCache) snapshot() MatchCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+//Caches the results of Match for up to size distinct user agents. A size of 0 or less disables the cache.
+//The cache is emptied whenever the repository is initialized again. Must not be called while matching.
+func (r *Repository) EnableMatchCache(size int) {
+	if size <= 0 {
+		r.matchCache = nil
+		return
+	}
+	r.matchCache = newMatchCache(size)
+}
+
+//Returns the hit, miss and eviction counters of the match cache, all zero when it is disabled.
+func (r *Repository) MatchCacheStats() MatchCacheStats {
+	if r.matchCache == nil {
+		return MatchCacheStats{}
+	}
+	return r.matchCache.snapshot()
+}
@@ -0,0 +1,81 @@
+package wurflgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchCacheEviction(t *testing.T) {
+	a, b, c := &Device{Id: "a"}, &Device{Id: "b"}, &Device{Id: "c"}
+	cache := newMatchCache(2)
+	cache.add("a", a)
+	cache.add("b", b)
+	if dev, found := cache.get("a"); !found || dev != a {
+		t.Errorf("get(a) = %v, %v, want a", dev, found)
+	}
+	//a was used last, so adding c evicts b.
+	cache.add("c", c)
+	if _, found := cache.get("b"); found {
+		t.Error("b was not evicted")
+	}
+	for ua, want := range map[string]*Device{"a": a, "c": c} {
+		if dev, found := cache.get(ua); !found || dev != want {
+			t.Errorf("get(%s) = %v, %v, want %s", ua, dev, found, ua)
+		}
+	}
+	//Adding a cached user agent again replaces its device without evicting anything.
+	cache.add("c", b)
+	if dev, _ := cache.get("c"); dev != b {
+		t.Errorf("get(c) after replacing it = %v, want b", dev)
+	}
+	want := MatchCacheStats{Hits: 4, Misses: 1, Evictions: 1, Size: 2}
+	if stats := cache.snapshot(); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestRepositoryMatchCache(t *testing.T) {
+	r := NewRepository()
+	r.EnableMatchCache(2)
+	load := func() {
+		wp, err := NewReaderProcessor("product_info", strings.NewReader(testDatabase), "wurfl.xml", r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wp.Logger = discardLogger
+		if err := wp.Process(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load()
+	first := r.Match(testUAs[0])
+	if again := r.Match(testUAs[0]); again != first {
+		t.Errorf("cached Match = %v, want %v", again, first)
+	}
+	r.Match(testUAs[2])
+	r.Match(testUAs[4])
+	want := MatchCacheStats{Hits: 1, Misses: 3, Evictions: 1, Size: 2}
+	if stats := r.MatchCacheStats(); stats != want {
+		t.Errorf("MatchCacheStats() = %+v, want %+v", stats, want)
+	}
+
+	//Loading again initializes the repository, which must not serve the devices it replaced.
+	load()
+	if stats := r.MatchCacheStats(); stats.Size != 0 {
+		t.Errorf("match cache holds %d entries after loading again, want 0", stats.Size)
+	}
+	if dev := r.Match(testUAs[0]); dev == first || dev == nil || dev.Id != first.Id {
+		t.Errorf("Match after loading again = %p %v, want a new %s", dev, dev, first.Id)
+	}
+	if stats := r.MatchCacheStats(); stats.Misses != 4 {
+		t.Errorf("Misses = %d after loading again, want 4", stats.Misses)
+	}
+
+	r.EnableMatchCache(0)
+	if stats := r.MatchCacheStats(); stats != (MatchCacheStats{}) {
+		t.Errorf("MatchCacheStats() of a disabled cache = %+v", stats)
+	}
+	if dev := r.Match(testUAs[0]); dev == nil || dev.Id != first.Id {
+		t.Errorf("Match without a cache = %v, want %s", dev, first.Id)
+	}
+}
@@ -1,10 +1,16 @@
 package wurflgo
 
 import (
-	"fmt"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
-	"os"
+	"io"
+	"log/slog"
+	"io/fs"
+	"hash"
 	"strings"
+	"time"
 	"github.com/iain17/wurflgo/stringSet"
 )
 
@@ -26,121 +32,396 @@ type XMLDevice struct{
 	UserAgent string `xml:"user_agent,attr"`
 	ActualDeviceRoot bool`xml:"actual_device_root,attr"`
 	Group []Grp `xml:"group"`
+	//Set when the file said fall_back="root", Parent is "" then. A patch can only make a device a root this way.
+	root bool
 }
 
 type WurflProcessor struct{
-	Groups stringSet.Set
+	Selection *Selection
 	DeferredDevices []string
 	DeviceList map[string]*XMLDevice
 	ProcessedDevices stringSet.Set
-	InFile *os.File
+	//The database, already decompressed, and the name used for it in logs and errors.
+	In io.Reader
+	Name string
+	//Patch files are opened from FS, or from the operating system when it is nil.
+	FS fs.FS
 	Out *Repository
+	Patches []string
+	FallBackPolicy FallBackPolicy
+	Logger *slog.Logger
+	Progress func(Progress)
+	started time.Time
+	source *source
+	checksum hash.Hash
+	wurflVersion string
+	dropped map[string]bool
 }
 
-func NewProcessor(groups string, infile string, out *Repository) (*WurflProcessor, error){
-	gpSet := stringSet.New()
-	gps := strings.Split(groups,",")
-	for _,V := range gps {
-		gpSet.Add(V)
+//Number of devices between two progress reports.
+const PROGRESS_INTERVAL = 1000
+
+//Reported to the progress callback while the database is loading.
+type Progress struct {
+	DevicesParsed int
+	Deferred int
+	Elapsed time.Duration
+}
+
+//Configures a WurflProcessor created by one of the loaders.
+type Option func(*WurflProcessor)
+
+//Routes the loader output through logger instead of slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(wp *WurflProcessor) {
+		wp.Logger = logger
+	}
+}
+
+//Calls fn every PROGRESS_INTERVAL devices and once more when loading has finished.
+func WithProgress(fn func(Progress)) Option {
+	return func(wp *WurflProcessor) {
+		wp.Progress = fn
+	}
+}
+
+//Applies the wurfl_patch.xml files in order on top of the main file. What every patch changed is
+//reported by Repository.Patches.
+func WithPatches(paths ...string) Option {
+	return func(wp *WurflProcessor) {
+		wp.Patches = append(wp.Patches, paths...)
+	}
+}
+
+//Keeps the retention policy of Cleanup in line with the selection, see Repository.SetRetention.
+func WithRetention(policy RetentionPolicy, capabilities ...string) Option {
+	return func(wp *WurflProcessor) {
+		wp.Out.SetRetention(policy, capabilities...)
+	}
+}
+
+//Param: selection: the capabilities to parse, see ParseSelection (product_info,display.resolution_width)
+//Param: infile: the wurfl xml file, it may be gzipped or zipped
+func NewProcessor(selection string, infile string, out *Repository) (*WurflProcessor, error){
+	return newProcessor(selection, nil, infile, out)
+}
+
+//Like NewProcessor, but reads the database and the patch files from fsys.
+func NewFSProcessor(selection string, fsys fs.FS, name string, out *Repository) (*WurflProcessor, error){
+	return newProcessor(selection, fsys, name, out)
+}
+
+//Like NewProcessor, but reads the database from r. Name is only used in logs and errors.
+//The processor does not close r.
+func NewReaderProcessor(selection string, r io.Reader, name string, out *Repository) (*WurflProcessor, error){
+	wurflp, err := newReaderProcessor(selection, out)
+	if err != nil{
+		return nil,err
+	}
+	wurflp.Name = name
+	if wurflp.source, err = newSource(r, name, wurflp.checksum); err != nil{
+		return nil,err
+	}
+	wurflp.In = wurflp.source
+	return wurflp,nil
+}
+
+func newProcessor(selection string, fsys fs.FS, name string, out *Repository) (*WurflProcessor, error){
+	wurflp, err := newReaderProcessor(selection, out)
+	if err != nil{
+		return nil,err
+	}
+	wurflp.Name = name
+	wurflp.FS = fsys
+	if wurflp.source, err = openSource(fsys, name, wurflp.checksum); err != nil{
+		return nil,err
+	}
+	wurflp.In = wurflp.source
+	return wurflp,nil
+}
+
+func newReaderProcessor(selection string, out *Repository) (*WurflProcessor, error){
+	sel, err := ParseSelection(selection)
+	if err != nil{
+		return nil,err
 	}
-	var err error
 	wurflp := new(WurflProcessor)
-	wurflp.Groups = gpSet
+	wurflp.Selection = sel
 	wurflp.Out = out
 	wurflp.DeferredDevices = []string{}
 	wurflp.ProcessedDevices = stringSet.New()
 	wurflp.DeviceList = make(map[string]*XMLDevice)
-	wurflp.InFile,err = os.OpenFile(infile,os.O_RDONLY,0666)
+	wurflp.dropped = make(map[string]bool)
+	wurflp.checksum = sha256.New()
+	wurflp.Logger = slog.Default()
+	return wurflp,nil
+}
+
+func (wp *WurflProcessor) Process() error{
+	return wp.ProcessContext(context.Background())
+}
+
+//Parses the database into the output repository, replacing what it held before. When ctx is cancelled
+//or any other error occurs, everything registered so far is discarded and the repository is left empty.
+func (wp *WurflProcessor) ProcessContext(ctx context.Context) error{
+	err := wp.process(ctx)
 	if err != nil{
-		return nil,err
+		wp.discard()
 	}
-	return wurflp,nil
+	return err
 }
 
-func (wp *WurflProcessor) Process(){
-	defer wp.InFile.Close()
-	var err error
-	dec := xml.NewDecoder(wp.InFile)
-	for {
-		t,_ := dec.Token()
-		if t == nil {
-			break
-		}
-		switch se := t.(type){
-		case xml.StartElement:
-			if se.Name.Local == "device"{
-				dev := new(XMLDevice)
-				if err = dec.DecodeElement(dev,&se); err == nil{
-					wp.DeviceList[dev.Id] = dev
-				}
-				if dev.Parent == "" || dev.Parent == "root"{
-					dev.Parent = ""
-					wp.save(dev)
-				} else {
-					if wp.ProcessedDevices.Get(dev.Parent) {
-						wp.save(dev)
-					} else {
-						wp.DeferredDevices = append(wp.DeferredDevices, dev.Id)
-					}
-				}
+func (wp *WurflProcessor) discard() {
+	wp.DeferredDevices = []string{}
+	wp.ProcessedDevices = stringSet.New()
+	wp.DeviceList = make(map[string]*XMLDevice)
+	wp.dropped = make(map[string]bool)
+	wp.Out.reset()
+}
 
-				//fmt.Printf("%#v\n",se)
+func (wp *WurflProcessor) process(ctx context.Context) error{
+	if wp.source != nil{
+		defer wp.source.Close()
+	}
+	wp.started = time.Now()
+	wp.Out.reset()
+	wp.Out.selection = wp.Selection
+	wp.Out.fallBackPolicy = wp.FallBackPolicy
+	wp.Logger.Info("loading wurfl database", "path", wp.Name)
+	if err := wp.decodeSource(ctx); err != nil{
+		return err
+	}
+	for _, patch := range wp.Patches{
+		report, err := wp.applyPatch(ctx, patch)
+		if err != nil{
+			return err
 		}
+		wp.Out.patches = append(wp.Out.patches, report)
+	}
+	wp.Out.wurflVersion = wp.wurflVersion
+	wp.Out.checksum = hex.EncodeToString(wp.checksum.Sum(nil))
+	if err := wp.ProcessDeferredDevicesContext(ctx); err != nil{
+		return err
+	}
+	wp.Out.Cleanup()
+	if err := wp.Out.InitializeContext(ctx); err != nil{
+		return err
+	}
+	wp.reportProgress()
+	wp.Logger.Info("wurfl database loaded", "devices", wp.Out.count(), "elapsed", time.Since(wp.started))
+	return nil
+}
 
-		//fmt.Printf("%#v\n",t)
+//Decodes the main file, reading it to the end so its checksum is complete.
+func (wp *WurflProcessor) decodeSource(ctx context.Context) error{
+	if err := wp.decode(ctx, wp.In, wp.Name, wp.add); err != nil{
+		return err
+	}
+	if wp.source != nil{
+		return wp.source.drain()
+	}
+	return nil
+}
+
+//Calls fn for every device element of the file and records the version of the database.
+func (wp *WurflProcessor) decode(ctx context.Context, r io.Reader, name string, fn func(*XMLDevice) error) error{
+	dec := xml.NewDecoder(r)
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xmlError(name, dec, err)
+		}
+		se, ok := t.(xml.StartElement)
+		if ok && se.Name.Local == "ver" && wp.wurflVersion == ""{
+			var version string
+			if err = dec.DecodeElement(&version, &se); err != nil{
+				return xmlError(name, dec, err)
+			}
+			wp.wurflVersion = strings.TrimSpace(version)
+		}
+		if ok && se.Name.Local == "device"{
+			if err := ctx.Err(); err != nil{
+				return err
+			}
+			dev := new(XMLDevice)
+			if err = dec.DecodeElement(dev,&se); err != nil{
+				return xmlError(name, dec, err)
+			}
+			if dev.Parent == "root"{
+				dev.Parent = ""
+				dev.root = true
+			}
+			if err = fn(dev); err != nil{
+				return err
+			}
 		}
 	}
-	wp.ProcessDeferredDevices()
-	wp.Out.Cleanup()
-	wp.Out.Initialize()
 }
 
+//Registers a device of the main file right away when its parent is known, otherwise defers it.
+//With patches every device is deferred, as a patch may still change it.
+func (wp *WurflProcessor) add(dev *XMLDevice) error{
+	wp.DeviceList[dev.Id] = dev
+	if len(wp.DeviceList) % PROGRESS_INTERVAL == 0{
+		wp.reportProgress()
+	}
+	if len(wp.Patches) == 0 && (dev.Parent == "" || wp.ProcessedDevices.Get(dev.Parent)){
+		return wp.save(dev)
+	}
+	wp.DeferredDevices = append(wp.DeferredDevices, dev.Id)
+	return nil
+}
+
+func (wp *WurflProcessor) reportProgress() {
+	if wp.Progress == nil {
+		return
+	}
+	wp.Progress(Progress{
+		DevicesParsed: len(wp.DeviceList),
+		Deferred: len(wp.DeferredDevices),
+		Elapsed: time.Since(wp.started),
+	})
+}
+
+func xmlError(path string, dec *xml.Decoder, err error) error{
+	line, _ := dec.InputPos()
+	if syntaxErr, ok := err.(*xml.SyntaxError); ok{
+		line = syntaxErr.Line
+	}
+	return &XMLError{Path: path, Line: line, Err: err}
+}
 
-func (wp *WurflProcessor) save(dev *XMLDevice) {
+func (wp *WurflProcessor) save(dev *XMLDevice) error{
 	capabilities := map[string]string{}
-	//fmt.Println(dev.Id)
 	for _,grp := range dev.Group{
-		if wp.Groups.Get(grp.Id){
-			for _,Cap := range grp.Capabilities{
+		if !wp.Selection.includesGroup(grp.Id){
+			continue
+		}
+		for _,Cap := range grp.Capabilities{
+			if wp.Selection.Includes(grp.Id, Cap.Name){
 				capabilities[Cap.Name] = Cap.Value
 			}
 		}
 	}
 	err := wp.Out.register(dev.Id, dev.UserAgent, dev.ActualDeviceRoot, capabilities, dev.Parent)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	wp.ProcessedDevices.Add(dev.Id)
+	return nil
 }
 
+//Registers the devices whose parent came later in the file. When a full pass over the deferred devices
+//registers none of them their parents will never be registered, the FallBackPolicy decides what happens then.
+func (wp *WurflProcessor)ProcessDeferredDevices() error{
+	return wp.ProcessDeferredDevicesContext(context.Background())
+}
 
-func (wp *WurflProcessor)ProcessDeferredDevices(){
-	fmt.Println("Processing Deferred Devices...")
+//Like ProcessDeferredDevices, but gives up and returns ctx.Err() as soon as ctx is cancelled.
+func (wp *WurflProcessor)ProcessDeferredDevicesContext(ctx context.Context) error{
+	wp.Logger.Info("processing deferred devices", "deferred", len(wp.DeferredDevices))
+	stalled := 0
 	for len(wp.DeferredDevices) > 0{
+		if err := ctx.Err(); err != nil{
+			return err
+		}
 		devId := wp.DeferredDevices[0]
 		dev := wp.DeviceList[devId]
-		if wp.ProcessedDevices.Get(dev.Parent){
-			wp.save(dev)
+		if dev.Parent == "" || wp.ProcessedDevices.Get(dev.Parent){
+			if err := wp.save(dev); err != nil{
+				return err
+			}
 			wp.DeferredDevices = wp.DeferredDevices[1:len(wp.DeferredDevices)]
+			stalled = 0
+			if len(wp.DeferredDevices) % PROGRESS_INTERVAL == 0{
+				wp.reportProgress()
+			}
 		} else {
+			if stalled >= len(wp.DeferredDevices){
+				if err := wp.resolveFallBacks(); err != nil{
+					return err
+				}
+				stalled = 0
+				continue
+			}
 			wp.DeferredDevices = append(wp.DeferredDevices[1:len(wp.DeferredDevices)], devId)
+			stalled++
 		}
 	}
+	return nil
+}
+
+//Loads a wurfl xml database.
+//Param: database: Path to the wurfl xml file, it may be gzipped or zipped (wurfl.zip, wurfl.xml.gz)
+//Param: groups: the capabilities you want to use separated by commas, whole groups or single
+//capabilities (product_info,display.resolution_width), see ParseSelection
+func Load(database string, groups string, options ...Option) (*Repository, error) {
+	return LoadContext(context.Background(), database, groups, options...)
+}
+
+//Like Load, but gives up and returns ctx.Err() as soon as ctx is cancelled.
+func LoadContext(ctx context.Context, database string, groups string, options ...Option) (*Repository, error) {
+	repository := NewRepository()
+	wp, err := NewProcessor(groups, database, repository)
+	if err != nil{
+		return nil, err
+	}
+	return wp.load(ctx, options)
+}
+
+//Loads a wurfl xml database from r, which may be gzipped or zipped.
+func LoadReader(r io.Reader, groups string, options ...Option) (*Repository, error) {
+	return LoadReaderContext(context.Background(), r, groups, options...)
+}
+
+func LoadReaderContext(ctx context.Context, r io.Reader, groups string, options ...Option) (*Repository, error) {
+	repository := NewRepository()
+	wp, err := NewReaderProcessor(groups, r, "reader", repository)
+	if err != nil{
+		return nil, err
+	}
+	return wp.load(ctx, options)
+}
+
+//Loads a wurfl xml database from fsys, for example an embed.FS holding wurfl.zip.
+//Patch files given with WithPatches are read from fsys as well.
+func LoadFS(fsys fs.FS, database string, groups string, options ...Option) (*Repository, error) {
+	return LoadFSContext(context.Background(), fsys, database, groups, options...)
+}
+
+func LoadFSContext(ctx context.Context, fsys fs.FS, database string, groups string, options ...Option) (*Repository, error) {
+	repository := NewRepository()
+	wp, err := NewFSProcessor(groups, fsys, database, repository)
+	if err != nil{
+		return nil, err
+	}
+	return wp.load(ctx, options)
+}
+
+func (wp *WurflProcessor) load(ctx context.Context, options []Option) (*Repository, error) {
+	for _, option := range options{
+		option(wp)
+	}
+	if err := wp.ProcessContext(ctx); err != nil{
+		return nil, err
+	}
+	return wp.Out, nil
 }
 
 /**
 * Param: groups: list of groups you want to use separated by commas
 * Param: database: Path to the wurfl xml file (product_info,xhtml_ui)
+*
+* Deprecated: use Load, which returns the error instead of logging it.
 */
 func New(database string, groups string) *Repository {
-	repository := NewRepository()
-	wp, err := NewProcessor(groups, database, repository)
+	repository, err := Load(database, groups)
 	if err != nil{
-		fmt.Println("An Error Occured %s", err.Error())
+		slog.Default().Error("loading wurfl database failed", "path", database, "error", err)
 		return nil
 	}
-	fmt.Println("Please wait loading wurfl database")
-	wp.Process()
-	fmt.Println(repository.count(), "devices loaded")
 	return repository
 }
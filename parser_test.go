@@ -0,0 +1,57 @@
+package wurflgo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+//Cancels the load once the processor reports the given message.
+type cancelHandler struct {
+	slog.Handler
+	message string
+	cancel  context.CancelFunc
+}
+
+func (h *cancelHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *cancelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Message == h.message {
+		h.cancel()
+	}
+	return nil
+}
+
+func TestProcessContextCancelled(t *testing.T) {
+	for _, message := range []string{"loading wurfl database", "processing deferred devices"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		repository := NewRepository()
+		wp, err := NewReaderProcessor("all", strings.NewReader(testDatabase), "test", repository)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wp.Logger = slog.New(&cancelHandler{Handler: slog.DiscardHandler, message: message, cancel: cancel})
+		if err := wp.ProcessContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: ProcessContext = %v, want context.Canceled", message, err)
+		}
+		if repository.count() != 0 || repository.Selection() != nil || repository.WurflVersion() != "" ||
+			repository.Checksum() != "" || len(repository.Patches()) != 0 || len(repository.AffectedDevices()) != 0 {
+			t.Errorf("%s: repository kept state after a cancelled load", message)
+		}
+		if dev := repository.Match(testUAs[6]); dev != nil {
+			t.Errorf("%s: Match = %v after a cancelled load", message, dev)
+		}
+	}
+}
+
+func TestLoadContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r, err := LoadReaderContext(ctx, strings.NewReader(testDatabase), "all", WithLogger(discardLogger)); r != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadReaderContext = %v, %v, want context.Canceled", r, err)
+	}
+}
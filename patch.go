@@ -0,0 +1,115 @@
+package wurflgo
+
+import (
+	"context"
+)
+
+//What a single patch file changed, in the order the patch lists its devices.
+//A CapabilityChange with an empty From adds a capability the device did not define itself, a ParentChange
+//with an empty To comes from fall_back="root" and makes the device a root of its own.
+type PatchReport struct {
+	Path              string             `json:"path"`
+	Added             []string           `json:"added"`
+	ParentChanges     []ParentChange     `json:"parent_changes"`
+	UAChanges         []UAChange         `json:"ua_changes"`
+	CapabilityChanges []CapabilityChange `json:"capability_changes"`
+}
+
+//Returns the reports of the patch files applied while loading, in the order they were applied.
+//A repository read from a cache only knows the paths of its patches, the lists of changes are empty.
+func (r *Repository) Patches() []*PatchReport {
+	return r.patches
+}
+
+//Merges a patch file into the parsed devices with the WURFL patch semantics:
+//a device with a new id is added, for a known id the user_agent and fall_back are replaced when the
+//patch sets them and the capabilities of every group are overridden or added one by one.
+func (wp *WurflProcessor) applyPatch(ctx context.Context, path string) (*PatchReport, error) {
+	src, err := openSource(wp.FS, path, wp.checksum)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	wp.Logger.Info("applying wurfl patch", "path", path)
+	report := newPatchReport(path)
+	err = wp.decode(ctx, src, path, func(patch *XMLDevice) error {
+		dev, found := wp.DeviceList[patch.Id]
+		if !found {
+			wp.DeviceList[patch.Id] = patch
+			wp.DeferredDevices = append(wp.DeferredDevices, patch.Id)
+			report.Added = append(report.Added, patch.Id)
+			return nil
+		}
+		report.patchDevice(dev, patch)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := src.drain(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func newPatchReport(path string) *PatchReport {
+	return &PatchReport{
+		Path:              path,
+		Added:             []string{},
+		ParentChanges:     []ParentChange{},
+		UAChanges:         []UAChange{},
+		CapabilityChanges: []CapabilityChange{},
+	}
+}
+
+func (report *PatchReport) patchDevice(dev, patch *XMLDevice) {
+	if patch.UserAgent != "" && patch.UserAgent != dev.UserAgent {
+		report.UAChanges = append(report.UAChanges, UAChange{Id: dev.Id, From: dev.UserAgent, To: patch.UserAgent})
+		dev.UserAgent = patch.UserAgent
+	}
+	if (patch.Parent != "" || patch.root) && patch.Parent != dev.Parent {
+		report.ParentChanges = append(report.ParentChanges, ParentChange{Id: dev.Id, From: dev.Parent, To: patch.Parent})
+		dev.Parent = patch.Parent
+	}
+	if patch.ActualDeviceRoot {
+		dev.ActualDeviceRoot = true
+	}
+	for _, patchGrp := range patch.Group {
+		grp := dev.group(patchGrp.Id)
+		for _, patchCap := range patchGrp.Capabilities {
+			from, found := grp.set(patchCap.Name, patchCap.Value)
+			if !found || from != patchCap.Value {
+				report.CapabilityChanges = append(report.CapabilityChanges, CapabilityChange{
+					Id:         dev.Id,
+					Capability: patchCap.Name,
+					From:       from,
+					To:         patchCap.Value,
+				})
+			}
+		}
+	}
+}
+
+//Returns the group with the given id, adding an empty one when the device does not have it yet.
+func (dev *XMLDevice) group(id string) *Grp {
+	for i := range dev.Group {
+		if dev.Group[i].Id == id {
+			return &dev.Group[i]
+		}
+	}
+	dev.Group = append(dev.Group, Grp{Id: id})
+	return &dev.Group[len(dev.Group)-1]
+}
+
+//Sets the capability and returns its previous value.
+func (grp *Grp) set(name, value string) (string, bool) {
+	for i := range grp.Capabilities {
+		if grp.Capabilities[i].Name == name {
+			from := grp.Capabilities[i].Value
+			grp.Capabilities[i].Value = value
+			return from, true
+		}
+	}
+	grp.Capabilities = append(grp.Capabilities, Capability{Name: name, Value: value})
+	return "", false
+}
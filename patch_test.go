@@ -0,0 +1,121 @@
+package wurflgo
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const testPatchSemantics = `<wurfl_patch><devices>
+<device id="nokia_7610_ver1" user_agent="Nokia7610/2.0 (5.0509.0) SymbianOS/7.0s Series60/2.1" fall_back="nokia_generic_series60">
+  <group id="product_info">
+    <capability name="model_name" value="7610"/>
+  </group>
+</device>
+<device id="nokia_6600_sub" user_agent="Nokia6600/2.0 (4.09.2) SymbianOS/7.0s Series60/2.0" fall_back="generic_mobile">
+  <group id="product_info">
+    <capability name="model_name" value="6600"/>
+  </group>
+</device>
+<device id="samsung_gt_i9100_ver1">
+  <group id="product_info">
+    <capability name="brand_name" value="SAMSUNG"/>
+  </group>
+  <group id="display">
+    <capability name="resolution_width" value="480"/>
+  </group>
+</device>
+<device id="google_chrome" fall_back="root">
+</device>
+</devices></wurfl_patch>`
+
+const testSecondPatch = `<wurfl_patch><devices>
+<device id="samsung_gt_i9100_ver1">
+  <group id="product_info">
+    <capability name="brand_name" value="Samsung Electronics"/>
+  </group>
+</device>
+</devices></wurfl_patch>`
+
+func loadPatchedRepository(t *testing.T) *Repository {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"wurfl.xml":        {Data: []byte(testDatabase)},
+		"wurfl_patch.xml":  {Data: []byte(testPatchSemantics)},
+		"second_patch.xml": {Data: []byte(testSecondPatch)},
+	}
+	r, err := LoadFS(fsys, "wurfl.xml", "all", WithLogger(discardLogger), WithRetention(RETAIN_ALL), WithPatches("wurfl_patch.xml", "second_patch.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestPatchReports(t *testing.T) {
+	r := loadPatchedRepository(t)
+	want := []*PatchReport{
+		{
+			Path:  "wurfl_patch.xml",
+			Added: []string{"nokia_7610_ver1"},
+			ParentChanges: []ParentChange{
+				{Id: "nokia_6600_sub", From: "nokia_generic_series60", To: "generic_mobile"},
+				{Id: "google_chrome", From: "generic_web_browser", To: ""},
+			},
+			UAChanges: []UAChange{
+				{Id: "nokia_6600_sub", From: "Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0", To: "Nokia6600/2.0 (4.09.2) SymbianOS/7.0s Series60/2.0"},
+			},
+			CapabilityChanges: []CapabilityChange{
+				{Id: "nokia_6600_sub", Capability: "model_name", From: "", To: "6600"},
+				{Id: "samsung_gt_i9100_ver1", Capability: "brand_name", From: "Samsung", To: "SAMSUNG"},
+			},
+		},
+		{
+			Path:          "second_patch.xml",
+			Added:         []string{},
+			ParentChanges: []ParentChange{},
+			UAChanges:     []UAChange{},
+			CapabilityChanges: []CapabilityChange{
+				{Id: "samsung_gt_i9100_ver1", Capability: "brand_name", From: "SAMSUNG", To: "Samsung Electronics"},
+			},
+		},
+	}
+	if got := r.Patches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Patches() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPatchedDevices(t *testing.T) {
+	r := loadPatchedRepository(t)
+	for _, test := range []struct {
+		id           string
+		parent       string
+		capabilities map[string]string
+	}{
+		{id: "nokia_7610_ver1", parent: "nokia_generic_series60", capabilities: map[string]string{"brand_name": "Nokia", "model_name": "7610", "is_wireless_device": "true"}},
+		{id: "nokia_6600_sub", parent: "generic_mobile", capabilities: map[string]string{"brand_name": "", "model_name": "6600", "is_wireless_device": "true"}},
+		{id: "samsung_gt_i9100_ver1", parent: "generic_android", capabilities: map[string]string{"brand_name": "Samsung Electronics", "resolution_width": "480"}},
+		{id: "google_chrome", parent: "", capabilities: map[string]string{"brand_name": "Google", "resolution_width": ""}},
+	} {
+		dev := r.DeviceByID(test.id)
+		if dev == nil {
+			t.Errorf("DeviceByID(%q) = nil", test.id)
+			continue
+		}
+		if dev.Parent != test.parent {
+			t.Errorf("%s: Parent = %q, want %q", test.id, dev.Parent, test.parent)
+		}
+		for name, value := range test.capabilities {
+			if got := dev.Capabilities[name]; got != value {
+				t.Errorf("%s: %s = %q, want %q", test.id, name, got, value)
+			}
+		}
+	}
+	for ua, id := range map[string]string{
+		"Nokia7610/2.0 (5.0509.0) SymbianOS/7.0s Series60/2.1": "nokia_7610_ver1",
+		"Nokia6600/2.0 (4.09.2) SymbianOS/7.0s Series60/2.0":   "nokia_6600_sub",
+	} {
+		if dev := r.Match(ua); dev == nil || dev.Id != id {
+			t.Errorf("Match(%q) = %v, want %s", ua, dev, id)
+		}
+	}
+}
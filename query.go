@@ -0,0 +1,151 @@
+package wurflgo
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//The capabilities Initialize builds an index for. Eq conditions on these are answered from the index.
+var IndexedCapabilities = []string{
+	"brand_name",
+	"model_name",
+	"marketing_name",
+	"device_os",
+	"device_os_version",
+	"mobile_browser",
+	"preferred_markup",
+	"is_wireless_device",
+	"is_tablet",
+}
+
+//A Condition tests one capability of a device. Devices that do not have the capability never match,
+//a Condition with only its Capability set matches every device that has it.
+type Condition struct {
+	Capability string
+	equals     *string
+	test       func(value string) bool
+}
+
+func (c Condition) matches(dev *Device) bool {
+	value, found := dev.Capability(c.Capability)
+	return found && (c.test == nil || c.test(value))
+}
+
+//Matches devices whose capability equals value.
+func Eq(capability, value string) Condition {
+	return Condition{
+		Capability: capability,
+		equals:     &value,
+		test:       func(v string) bool { return v == value },
+	}
+}
+
+//Matches devices whose capability starts with prefix.
+func Prefix(capability, prefix string) Condition {
+	return Condition{
+		Capability: capability,
+		test:       func(v string) bool { return strings.HasPrefix(v, prefix) },
+	}
+}
+
+//Matches devices whose capability matches the regular expression.
+func Regex(capability string, rx *regexp.Regexp) Condition {
+	return Condition{
+		Capability: capability,
+		test:       rx.MatchString,
+	}
+}
+
+//Matches devices whose capability is a number between min and max inclusive.
+func Range(capability string, min, max float64) Condition {
+	return Condition{
+		Capability: capability,
+		test: func(v string) bool {
+			f, err := strconv.ParseFloat(v, 64)
+			return err == nil && f >= min && f <= max
+		},
+	}
+}
+
+//Matches devices whose capability is a number of at least min.
+func AtLeast(capability string, min float64) Condition {
+	return Range(capability, min, math.Inf(1))
+}
+
+//Matches devices whose capability is a number of at most max.
+func AtMost(capability string, max float64) Condition {
+	return Range(capability, math.Inf(-1), max)
+}
+
+type capabilityIndex struct {
+	ids    []string
+	values map[string]map[string][]string
+}
+
+func (r *Repository) buildIndex() *capabilityIndex {
+	index := &capabilityIndex{
+		ids:    make([]string, 0, len(r.devices)),
+		values: make(map[string]map[string][]string),
+	}
+	for id := range r.devices {
+		index.ids = append(index.ids, id)
+	}
+	sort.Strings(index.ids)
+	for _, name := range IndexedCapabilities {
+		index.values[name] = make(map[string][]string)
+	}
+	for _, id := range index.ids {
+		dev := r.devices[id]
+		for _, name := range IndexedCapabilities {
+			if value, found := dev.Capability(name); found {
+				index.values[name][value] = append(index.values[name][value], id)
+			}
+		}
+	}
+	return index
+}
+
+//Returns the ids of the devices matching every condition, sorted by id.
+func (r *Repository) QueryIds(conditions ...Condition) []string {
+	index := r.index
+	if index == nil {
+		index = r.buildIndex()
+	}
+	candidates := index.ids
+	for _, c := range conditions {
+		if c.equals == nil {
+			continue
+		}
+		if values, indexed := index.values[c.Capability]; indexed && len(values[*c.equals]) < len(candidates) {
+			candidates = values[*c.equals]
+		}
+	}
+	ids := []string{}
+	for _, id := range candidates {
+		dev := r.devices[id]
+		matches := true
+		for _, c := range conditions {
+			if !c.matches(dev) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+//Returns the devices matching every condition, sorted by id.
+func (r *Repository) Query(conditions ...Condition) []*Device {
+	ids := r.QueryIds(conditions...)
+	devices := make([]*Device, len(ids))
+	for i, id := range ids {
+		devices[i] = r.devices[id]
+	}
+	return devices
+}
@@ -0,0 +1,56 @@
+package wurflgo
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	r := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	android := []string{"generic_android", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12"}
+	for _, test := range []struct {
+		name       string
+		conditions []Condition
+		ids        []string
+	}{
+		{name: "indexed eq", conditions: []Condition{Eq("device_os", "Android")}, ids: android},
+		{name: "indexed empty value", conditions: []Condition{Eq("brand_name", "")}, ids: []string{"generic", "generic_android", "generic_mobile", "generic_web_browser"}},
+		{name: "eq", conditions: []Condition{Eq("resolution_width", "1024")}, ids: []string{"generic_web_browser", "google_chrome"}},
+		{name: "prefix", conditions: []Condition{Prefix("model_name", "SM-")}, ids: []string{"samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12"}},
+		{name: "regex", conditions: []Condition{Regex("model_name", regexp.MustCompile(`^(GT|Chr)`))}, ids: []string{"google_chrome", "samsung_gt_i9100_ver1"}},
+		{name: "range", conditions: []Condition{Range("resolution_width", 480, 1024)}, ids: []string{"generic_web_browser", "google_chrome", "samsung_gt_i9100_ver1"}},
+		{name: "at least", conditions: []Condition{AtLeast("resolution_width", 1080)}, ids: []string{"samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12"}},
+		{name: "at most", conditions: []Condition{AtMost("resolution_width", 90), Eq("is_wireless_device", "true")}, ids: []string{"generic_android", "generic_mobile", "nokia_6600_sub", "nokia_generic_series60"}},
+		{name: "range of text", conditions: []Condition{Range("brand_name", 0, 100)}, ids: []string{}},
+		{name: "indexed and not indexed", conditions: []Condition{Eq("brand_name", "Samsung"), AtMost("resolution_width", 480)}, ids: []string{"samsung_gt_i9100_ver1"}},
+		{name: "missing capability", conditions: []Condition{Eq("no_such_capability", "")}, ids: []string{}},
+		{name: "has capability", conditions: []Condition{{Capability: "device_os"}}, ids: []string{"generic", "generic_android", "generic_mobile", "generic_web_browser", "google_chrome", "nokia_6600_sub", "nokia_generic_series60", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12"}},
+		{name: "no conditions", ids: []string{"generic", "generic_android", "generic_mobile", "generic_web_browser", "google_chrome", "nokia_6600_sub", "nokia_generic_series60", "samsung_gt_i9100_ver1", "samsung_sm_g991b_ver1", "samsung_sm_g991b_ver1_suban12"}},
+	} {
+		if ids := r.QueryIds(test.conditions...); !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%s: QueryIds = %v, want %v", test.name, ids, test.ids)
+		}
+		devices := r.Query(test.conditions...)
+		ids := make([]string, len(devices))
+		for i, dev := range devices {
+			ids[i] = dev.Id
+		}
+		if len(devices) != len(test.ids) || (len(ids) > 0 && !reflect.DeepEqual(ids, test.ids)) {
+			t.Errorf("%s: Query = %v, want %v", test.name, ids, test.ids)
+		}
+	}
+}
+
+func TestQueryWithoutIndex(t *testing.T) {
+	r := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	if r.index == nil {
+		t.Fatal("Initialize did not build the capability index")
+	}
+	conditions := []Condition{Eq("brand_name", "Samsung"), Eq("device_os", "Android")}
+	indexed := r.QueryIds(conditions...)
+	r.index = nil
+	if scanned := r.QueryIds(conditions...); !reflect.DeepEqual(indexed, scanned) {
+		t.Errorf("QueryIds with the index = %v, without = %v", indexed, scanned)
+	}
+}
@@ -1,11 +1,9 @@
 package wurflgo
 
 import (
-	"errors"
+	"context"
 )
 
-var chain = NewChain()
-
 type DeviceProperties struct {
 	BrandName string `json:"brand_name"`
 	ModelName string `json:"model_name"`
@@ -17,6 +15,8 @@ type DeviceProperties struct {
 	DeviceOsVersion string `json:"device_os_version"`
 	BrowserName string `json:"mobile_browser"`
 	BrowserVersion string `json:"mobile_browser_version"`
+	//The property names that were in the parsed data, the others are empty because they were never selected.
+	Present map[string]bool `json:"-"`
 }
 
 type Device struct {
@@ -29,14 +29,40 @@ type Device struct {
 	Properties	 *DeviceProperties
 }
 
+//Decides which capabilities Cleanup keeps on every device.
+type RetentionPolicy int
+
+const (
+	//Only keep the DeviceProperties and the capabilities the selection names one by one. This is the default.
+	RETAIN_PROPERTIES RetentionPolicy = iota
+	//Keep every capability selected by the parser.
+	RETAIN_ALL
+	//Keep the capabilities named in the whitelist.
+	RETAIN_WHITELIST
+)
+
 type Repository struct {
 	initialized bool
 	devices map[string]*Device
+	chain *Chain
+	retention RetentionPolicy
+	retained []string
+	selection *Selection
+	fallBackPolicy FallBackPolicy
+	patches []*PatchReport
+	affected []AffectedDevice
+	wurflVersion string
+	checksum string
+	index *capabilityIndex
+	uaHeaders []string
+	matchCache *matchCache
 }
 
+//Every repository owns its own matching chain, so several databases can be loaded side by side.
 func NewRepository() *Repository {
 	r := new(Repository)
 	r.devices = make(map[string]*Device)
+	r.chain = NewWurflChain()
 	return r
 }
 
@@ -48,17 +74,60 @@ func (r *Repository) count() int {
 	return len(r.devices)
 }
 
+//Match is safe for concurrent use once the repository has been initialized.
 func (r *Repository) Match(ua string) *Device {
-	m := chain.Match(ua)
-	return r.find(m)
+	if r.matchCache == nil {
+		return r.find(r.chain.Match(ua))
+	}
+	if dev, found := r.matchCache.get(ua); found {
+		return dev
+	}
+	dev := r.find(r.chain.Match(ua))
+	r.matchCache.add(ua, dev)
+	return dev
 }
 
 func (r *Repository) Initialize() {
+	r.InitializeContext(context.Background())
+}
+
+//Feeds every device into the matching chain. When ctx is cancelled the partially filled chain is discarded.
+func (r *Repository) InitializeContext(ctx context.Context) error {
 	if r.initialized {
-		return
+		return nil
 	}
 	for _, dev := range r.devices {
-		chain.Filter(dev.UA, dev.Id)
+		if err := ctx.Err(); err != nil {
+			r.chain = NewWurflChain()
+			return err
+		}
+		r.chain.Filter(dev.UA, dev.Id)
+	}
+	r.chain.Prepare()
+	r.index = r.buildIndex()
+	r.purgeMatchCache()
+	r.initialized = true
+	return nil
+}
+
+//Empties the repository, dropping its devices, matching chain and what it recorded about the database.
+func (r *Repository) reset() {
+	r.devices = make(map[string]*Device)
+	r.chain = NewWurflChain()
+	r.index = nil
+	r.selection = nil
+	r.fallBackPolicy = FALLBACK_FAIL
+	r.wurflVersion = ""
+	r.checksum = ""
+	r.patches = nil
+	r.affected = nil
+	r.purgeMatchCache()
+	r.initialized = false
+}
+
+func (r *Repository) purgeMatchCache() {
+	if r.matchCache != nil {
+		r.matchCache.purge()
 	}
 }
 
@@ -66,6 +135,7 @@ func (r *Repository) register(id, ua string, actualDeviceRoot bool, capabilities
 	dev := new(Device)
 	dev.Id = id
 	dev.UA = ua
+	dev.ActualDeviceRoot = actualDeviceRoot
 	dev.Children = make(map[string]bool)
 	dev.Capabilities = make(map[string]string)
 	dev.Parent = parent
@@ -74,7 +144,7 @@ func (r *Repository) register(id, ua string, actualDeviceRoot bool, capabilities
 		parentDevice.Children[dev.Id] = true
 	} else {
 		if dev.Parent != "" {
-			return errors.New("Unregistered Parent Device:"+dev.Id)
+			return &OrphanError{Id: dev.Id, Parent: dev.Parent}
 		}
 	}
 	r.devices[dev.Id] = dev
@@ -97,17 +167,89 @@ func (r *Repository) setCapabilities(device *Device, childCapabilities map[strin
 	}
 }
 
-//We're only interested in the device properties. So clear the Capabilities array afterwards.
-//So that we don't save all that useless crap in our cache file
+//Sets which capabilities are kept by Cleanup. The capabilities are only used with RETAIN_WHITELIST.
+//Must be called before the database is processed.
+func (r *Repository) SetRetention(policy RetentionPolicy, capabilities ...string) {
+	r.retention = policy
+	r.retained = capabilities
+}
+
+func (r *Repository) Retention() (RetentionPolicy, []string) {
+	return r.retention, r.retained
+}
+
+//Returns the <version><ver> string of the database, such as "www.wurflpro.com - 2017-01-01".
+func (r *Repository) WurflVersion() string {
+	return r.wurflVersion
+}
+
+//Returns the hex encoded sha256 of the database file followed by its patch files, as they were read
+//before decompression.
+func (r *Repository) Checksum() string {
+	return r.checksum
+}
+
+//Returns the capabilities the database was parsed with, nil when the repository was not loaded by the parser.
+func (r *Repository) Selection() *Selection {
+	return r.selection
+}
+
+//Fills in the device properties and drops every capability the retention policy does not keep,
+//so that we don't save all that useless crap in our cache file.
 func (r *Repository) Cleanup() {
-	for id, dev := range r.devices {
-		r.devices[id].Properties = dev.getProperties()
-		r.devices[id].Capabilities = nil
+	whitelist := make(map[string]bool)
+	for _, name := range r.retained {
+		whitelist[name] = true
+	}
+	named := make(map[string]bool)
+	if r.selection != nil {
+		named = r.selection.named()
+	}
+	for _, dev := range r.devices {
+		dev.Properties = dev.getProperties()
+		switch r.retention {
+		case RETAIN_ALL:
+		case RETAIN_WHITELIST:
+			dev.Capabilities = dev.retain(whitelist)
+		default:
+			dev.Capabilities = dev.retain(named)
+		}
+	}
+}
+
+func (dev *Device) retain(names map[string]bool) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	capabilities := make(map[string]string)
+	for name, value := range dev.Capabilities {
+		if names[name] {
+			capabilities[name] = value
+		}
+	}
+	return capabilities
+}
+
+//Returns the value of a retained capability. Falls back to the device properties when the capability itself was not kept.
+func (dev *Device) Capability(name string) (string, bool) {
+	if value, found := dev.Capabilities[name]; found {
+		return value, true
+	}
+	if dev.Properties != nil {
+		return dev.Properties.get(name)
 	}
+	return "", false
 }
 
 func (dev *Device) getProperties() *DeviceProperties {
+	present := make(map[string]bool)
+	for _, name := range PropertyNames {
+		if _, found := dev.Capabilities[name]; found {
+			present[name] = true
+		}
+	}
 	return &DeviceProperties{
+		Present: present,
 		BrandName: dev.Capabilities["brand_name"],
 		ModelName: dev.Capabilities["model_name"],
 		MarketingName: dev.Capabilities["marketing_name"],
@@ -121,7 +263,53 @@ func (dev *Device) getProperties() *DeviceProperties {
 	}
 }
 
-func init() {
+//The capability names of the DeviceProperties fields.
+var PropertyNames = []string{
+	"brand_name",
+	"model_name",
+	"marketing_name",
+	"preferred_markup",
+	"resolution_width",
+	"resolution_height",
+	"device_os",
+	"device_os_version",
+	"mobile_browser",
+	"mobile_browser_version",
+}
+
+//Returns the property with the given capability name, found is false when it was not parsed.
+func (p *DeviceProperties) get(name string) (string, bool) {
+	if !p.Present[name] {
+		return "", false
+	}
+	switch name {
+	case "brand_name":
+		return p.BrandName, true
+	case "model_name":
+		return p.ModelName, true
+	case "marketing_name":
+		return p.MarketingName, true
+	case "preferred_markup":
+		return p.PreferredMarkup, true
+	case "resolution_width":
+		return p.ResolutionWidth, true
+	case "resolution_height":
+		return p.ResolutionHeight, true
+	case "device_os":
+		return p.DeviceOs, true
+	case "device_os_version":
+		return p.DeviceOsVersion, true
+	case "mobile_browser":
+		return p.BrowserName, true
+	case "mobile_browser_version":
+		return p.BrowserVersion, true
+	}
+	return "", false
+}
+
+//Builds a chain with every handler wired in the order the WURFL matching algorithm expects.
+func NewWurflChain() *Chain {
+	chain := NewChain()
 	genericNormalizers := CreateGenericNormalizers()
 	chain.AddHandler(NewJavaMidletHandler(genericNormalizers))
 	chain.AddHandler(NewSmartTVHandler(genericNormalizers))
@@ -199,7 +387,7 @@ func init() {
 
 	// All other requests.
 	chain.AddHandler(NewCatchAllHandler(genericNormalizers))
-
+	return chain
 }
 
 func CreateGenericNormalizers() *UserAgentNormalizer {
@@ -0,0 +1,176 @@
+package wurflgo
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//A small database covering the handlers the tests need.
+const testDatabase = `<?xml version="1.0" encoding="UTF-8"?>
+<wurfl>
+<version><ver>test</ver></version>
+<devices>
+<device id="generic" user_agent="" fall_back="root">
+  <group id="product_info">
+    <capability name="brand_name" value=""/>
+    <capability name="model_name" value=""/>
+    <capability name="is_wireless_device" value="false"/>
+    <capability name="device_os" value=""/>
+  </group>
+  <group id="display">
+    <capability name="resolution_width" value="90"/>
+    <capability name="max_image_width" value="90"/>
+  </group>
+</device>
+<device id="generic_mobile" user_agent="DO_NOT_MATCH_GENERIC_MOBILE" fall_back="generic">
+  <group id="product_info">
+    <capability name="is_wireless_device" value="true"/>
+  </group>
+</device>
+<device id="generic_web_browser" user_agent="DO_NOT_MATCH_GENERIC_WEB_BROWSER" fall_back="generic">
+  <group id="display">
+    <capability name="resolution_width" value="1024"/>
+  </group>
+</device>
+<device id="generic_android" user_agent="DO_NOT_MATCH_GENERIC_ANDROID" fall_back="generic_mobile">
+  <group id="product_info">
+    <capability name="device_os" value="Android"/>
+  </group>
+</device>
+<device id="samsung_gt_i9100_ver1" user_agent="Mozilla/5.0 (Linux; U; Android 2.3.3; xx-xx; GT-I9100 Build/GINGERBREAD) AppleWebKit/533.1 (KHTML, like Gecko) Version/4.0 Mobile Safari/533.1" fall_back="generic_android" actual_device_root="true">
+  <group id="product_info">
+    <capability name="brand_name" value="Samsung"/>
+    <capability name="model_name" value="GT-I9100"/>
+  </group>
+  <group id="display">
+    <capability name="resolution_width" value="480"/>
+  </group>
+</device>
+<device id="samsung_sm_g991b_ver1" user_agent="Mozilla/5.0 (Linux; Android 11; SM-G991B Build/RP1A) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.91 Mobile Safari/537.36" fall_back="generic_android" actual_device_root="true">
+  <group id="product_info">
+    <capability name="brand_name" value="Samsung"/>
+    <capability name="model_name" value="SM-G991B"/>
+  </group>
+  <group id="display">
+    <capability name="resolution_width" value="1080"/>
+  </group>
+</device>
+<device id="samsung_sm_g991b_ver1_suban12" user_agent="Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.117 Mobile Safari/537.36" fall_back="samsung_sm_g991b_ver1">
+</device>
+<device id="google_chrome" user_agent="Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0 Safari/537.36" fall_back="generic_web_browser">
+  <group id="product_info">
+    <capability name="brand_name" value="Google"/>
+    <capability name="model_name" value="Chrome"/>
+  </group>
+</device>
+<device id="nokia_generic_series60" user_agent="Nokia6600/1.0" fall_back="generic_mobile">
+  <group id="product_info">
+    <capability name="brand_name" value="Nokia"/>
+  </group>
+</device>
+<device id="nokia_6600_sub" user_agent="Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0" fall_back="nokia_generic_series60">
+</device>
+</devices>
+</wurfl>`
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func loadTestRepository(t testing.TB, selection string, options ...Option) *Repository {
+	t.Helper()
+	r, err := LoadReader(strings.NewReader(testDatabase), selection, append([]Option{WithLogger(discardLogger)}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+var testUAs = []string{
+	"Mozilla/5.0 (Linux; U; Android 2.3.3; xx-xx; GT-I9100 Build/GINGERBREAD) AppleWebKit/533.1 (KHTML, like Gecko) Version/4.0 Mobile Safari/533.1",
+	"Mozilla/5.0 (Linux; U; Android 2.3.5; en-us; GT-I9100 Build/GINGERBREAD) AppleWebKit/533.1 (KHTML, like Gecko) Version/4.0 Mobile Safari/533.1",
+	"Mozilla/5.0 (Linux; Android 11; SM-G991B Build/RP1A) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.91 Mobile Safari/537.36",
+	"Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.0.0 Mobile Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Nokia6600/2.0 (4.09.1) SymbianOS/7.0s Series60/2.0",
+	"Nokia6600/1.0",
+	"Opera/9.80 (J2ME/MIDP; Opera Mini/4.2.14912/870; U; id) Presto/2.4.15",
+	"",
+}
+
+func TestMatch(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	for ua, id := range map[string]string{
+		testUAs[0]: "samsung_gt_i9100_ver1",
+		testUAs[2]: "samsung_sm_g991b_ver1",
+		testUAs[4]: "google_chrome",
+		testUAs[6]: "nokia_6600_sub",
+	} {
+		if dev := r.Match(ua); dev == nil || dev.Id != id {
+			t.Errorf("Match(%q) = %v, want %s", ua, dev, id)
+		}
+	}
+}
+
+//Run with -race, Match has to be safe to call from many goroutines once the repository is initialized.
+func TestMatchConcurrent(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	expected := make([]string, len(testUAs))
+	for i, ua := range testUAs {
+		expected[i] = deviceId(r.Match(ua))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				i := (g + n) % len(testUAs)
+				if id := deviceId(r.Match(testUAs[i])); id != expected[i] {
+					t.Errorf("Match(%q) = %s, want %s", testUAs[i], id, expected[i])
+				}
+				if dev, trace := r.MatchWithTrace(testUAs[i]); deviceId(dev) != expected[i] || (dev != nil && trace.DeviceId != dev.Id) {
+					t.Errorf("MatchWithTrace(%q) = %s, want %s", testUAs[i], deviceId(dev), expected[i])
+				}
+			}
+		}(g)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, dev := range r.MatchBatch(testUAs, 4) {
+			if deviceId(dev) != expected[i] {
+				t.Errorf("MatchBatch(%q) = %s, want %s", testUAs[i], deviceId(dev), expected[i])
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestMatchConcurrentWithCache(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	r.EnableMatchCache(4)
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				r.Match(testUAs[(g*n)%len(testUAs)])
+			}
+		}(g)
+	}
+	wg.Wait()
+	if stats := r.MatchCacheStats(); stats.Hits+stats.Misses != 1600 {
+		t.Errorf("cache saw %d lookups, want 1600", stats.Hits+stats.Misses)
+	}
+}
+
+func deviceId(dev *Device) string {
+	if dev == nil {
+		return ""
+	}
+	return dev.Id
+}
@@ -0,0 +1,52 @@
+package wurflgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+//The headers MatchRequest reads the user agent from, most informative first.
+//Transcoding proxies and proxy browsers such as Opera Mini and UC Browser send their own user agent
+//in User-Agent and forward the one of the device in a side-loaded header.
+var DefaultUAHeaders = []string{
+	"X-OperaMini-Phone-UA",
+	"X-Device-User-Agent",
+	"X-Original-User-Agent",
+	"X-UCBrowser-Device-UA",
+	"Device-Stock-UA",
+	"User-Agent",
+}
+
+//Sets the headers MatchRequest reads the user agent from, in order of precedence.
+//Passing no headers restores DefaultUAHeaders. Must not be called while requests are being matched.
+func (r *Repository) SetUAHeaders(headers ...string) {
+	r.uaHeaders = headers
+}
+
+//Returns the user agent of the request from the first non empty header in the precedence order,
+//together with the name of that header. A reduced User-Agent is rebuilt from the client hints sent with it.
+func (r *Repository) RequestUA(req *http.Request) (string, string) {
+	headers := r.uaHeaders
+	if len(headers) == 0 {
+		headers = DefaultUAHeaders
+	}
+	for _, header := range headers {
+		ua := strings.TrimSpace(req.Header.Get(header))
+		if ua == "" {
+			continue
+		}
+		if http.CanonicalHeaderKey(header) == "User-Agent" {
+			if hints, err := ParseClientHints(req.Header); err == nil && hints != nil {
+				ua = hints.ApplyTo(ua)
+			}
+		}
+		return ua, header
+	}
+	return "", ""
+}
+
+//Matches the user agent picked by RequestUA and reports the header it was read from.
+func (r *Repository) MatchRequest(req *http.Request) (*Device, string) {
+	ua, header := r.RequestUA(req)
+	return r.Match(ua), header
+}
@@ -0,0 +1,73 @@
+package wurflgo
+
+import (
+	"net/http"
+	"testing"
+)
+
+const operaMiniUA = "Opera/9.80 (J2ME/MIDP; Opera Mini/7.1.32052/29.3417; U; en) Presto/2.8.119 Version/11.10"
+
+func newTestRequest(headers map[string]string) *http.Request {
+	req, _ := http.NewRequest("GET", "/", nil)
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
+	return req
+}
+
+func TestRequestUA(t *testing.T) {
+	r := loadTestRepository(t, "product_info")
+	for _, test := range []struct {
+		name    string
+		headers map[string]string
+		ua      string
+		header  string
+	}{
+		{name: "user agent only", headers: map[string]string{"User-Agent": testUAs[4]}, ua: testUAs[4], header: "User-Agent"},
+		{name: "opera mini", headers: map[string]string{"User-Agent": operaMiniUA, "X-OperaMini-Phone-UA": testUAs[6]}, ua: testUAs[6], header: "X-OperaMini-Phone-UA"},
+		{name: "first of several side-loaded headers", headers: map[string]string{
+			"User-Agent":            operaMiniUA,
+			"Device-Stock-UA":       testUAs[0],
+			"X-Original-User-Agent": testUAs[2],
+			"X-Device-User-Agent":   testUAs[6],
+		}, ua: testUAs[6], header: "X-Device-User-Agent"},
+		{name: "blank header is skipped", headers: map[string]string{"User-Agent": testUAs[4], "X-OperaMini-Phone-UA": "  "}, ua: testUAs[4], header: "User-Agent"},
+		{name: "surrounding space is trimmed", headers: map[string]string{"X-UCBrowser-Device-UA": " " + testUAs[0] + " "}, ua: testUAs[0], header: "X-UCBrowser-Device-UA"},
+		{name: "no user agent", headers: map[string]string{"Accept": "*/*"}},
+	} {
+		ua, header := r.RequestUA(newTestRequest(test.headers))
+		if ua != test.ua || header != test.header {
+			t.Errorf("%s: RequestUA = %q, %q, want %q, %q", test.name, ua, header, test.ua, test.header)
+		}
+	}
+}
+
+func TestSetUAHeaders(t *testing.T) {
+	r := loadTestRepository(t, "product_info")
+	req := newTestRequest(map[string]string{
+		"User-Agent":           testUAs[4],
+		"X-OperaMini-Phone-UA": testUAs[6],
+		"X-Custom-UA":          testUAs[0],
+	})
+	for _, test := range []struct {
+		headers []string
+		id      string
+		header  string
+	}{
+		{id: "nokia_6600_sub", header: "X-OperaMini-Phone-UA"},
+		{headers: []string{"User-Agent", "X-OperaMini-Phone-UA"}, id: "google_chrome", header: "User-Agent"},
+		{headers: []string{"x-custom-ua", "User-Agent"}, id: "samsung_gt_i9100_ver1", header: "x-custom-ua"},
+		{headers: []string{"X-Missing-UA", "X-OperaMini-Phone-UA"}, id: "nokia_6600_sub", header: "X-OperaMini-Phone-UA"},
+		{headers: []string{}, id: "nokia_6600_sub", header: "X-OperaMini-Phone-UA"},
+	} {
+		r.SetUAHeaders(test.headers...)
+		dev, header := r.MatchRequest(req)
+		if dev == nil || dev.Id != test.id || header != test.header {
+			t.Errorf("SetUAHeaders(%q): MatchRequest = %v, %q, want %s, %q", test.headers, dev, header, test.id, test.header)
+		}
+	}
+	r.SetUAHeaders("X-Missing-UA")
+	if ua, header := r.RequestUA(req); ua != "" || header != "" {
+		t.Errorf("RequestUA without any of the headers = %q, %q, want nothing", ua, header)
+	}
+}
@@ -0,0 +1,106 @@
+package wurflgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Wildcard matching every group or every capability of a group.
+const SELECT_ALL = "*"
+
+var ErrSelection = errors.New("invalid capability selection")
+
+//Decides which capabilities the parser keeps from the database.
+//A selection is written as a comma separated list of entries:
+//	all or *            every capability of every group
+//	product_info        every capability of the product_info group
+//	product_info.*      the same
+//	display.resolution_width  a single capability
+//	*.brand_name        a single capability, whatever group it is in
+type Selection struct {
+	all          bool
+	groups       map[string]bool
+	capabilities map[string]map[string]bool
+}
+
+//Parses a selection. Empty entries are skipped, so "" selects nothing.
+func ParseSelection(spec string) (*Selection, error) {
+	s := &Selection{
+		groups:       make(map[string]bool),
+		capabilities: make(map[string]map[string]bool),
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "all" || entry == SELECT_ALL {
+			s.all = true
+			continue
+		}
+		parts := strings.Split(entry, ".")
+		if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return nil, fmt.Errorf("%w: %q", ErrSelection, entry)
+		}
+		if len(parts) == 1 || parts[1] == SELECT_ALL {
+			if parts[0] == SELECT_ALL {
+				s.all = true
+				continue
+			}
+			s.groups[parts[0]] = true
+			continue
+		}
+		if s.capabilities[parts[0]] == nil {
+			s.capabilities[parts[0]] = make(map[string]bool)
+		}
+		s.capabilities[parts[0]][parts[1]] = true
+	}
+	return s, nil
+}
+
+//Reports whether the capability of the given group is selected.
+func (s *Selection) Includes(group, capability string) bool {
+	if s.all || s.groups[group] {
+		return true
+	}
+	return s.capabilities[group][capability] || s.capabilities[SELECT_ALL][capability]
+}
+
+//Reports whether any capability of the group can be selected, so the parser can skip the others.
+func (s *Selection) includesGroup(group string) bool {
+	return s.all || s.groups[group] || len(s.capabilities[group]) > 0 || len(s.capabilities[SELECT_ALL]) > 0
+}
+
+//Returns the names of the capabilities selected one by one, such as max_image_width for display.max_image_width.
+func (s *Selection) named() map[string]bool {
+	names := make(map[string]bool)
+	for _, capabilities := range s.capabilities {
+		for capability := range capabilities {
+			names[capability] = true
+		}
+	}
+	return names
+}
+
+//Returns the selection in the form ParseSelection accepts, with the entries sorted.
+func (s *Selection) String() string {
+	if s.all {
+		return "all"
+	}
+	entries := []string{}
+	for group := range s.groups {
+		entries = append(entries, group+"."+SELECT_ALL)
+	}
+	for group, capabilities := range s.capabilities {
+		if s.groups[group] {
+			continue
+		}
+		for capability := range capabilities {
+			entries = append(entries, group+"."+capability)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,148 @@
+package wurflgo
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+var ErrEmptyArchive = errors.New("zip archive contains no xml file")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+//A database or patch file being read. The raw bytes are copied to the checksum as they are read.
+type source struct {
+	io.Reader
+	raw     io.Reader
+	closers []io.Closer
+}
+
+//Reads r to the end, through the decompressor when it recognizes the container.
+func newSource(r io.Reader, name string, checksum io.Writer) (*source, error) {
+	raw := io.TeeReader(r, checksum)
+	decompressed, closer, err := decompress(raw, name)
+	if err != nil {
+		return nil, err
+	}
+	return &source{Reader: decompressed, raw: raw, closers: []io.Closer{closer}}, nil
+}
+
+//Opens a database or patch file from fsys, or from the operating system when fsys is nil.
+func openSource(fsys fs.FS, name string, checksum io.Writer) (*source, error) {
+	var f io.ReadCloser
+	var err error
+	if fsys == nil {
+		f, err = openFile(name)
+	} else {
+		f, err = fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = &MissingFileError{Path: name, Err: err}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	src, err := newSource(f, name, checksum)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	src.closers = append(src.closers, f)
+	return src, nil
+}
+
+//Reads what the xml decoder left, such as the gzip trailer, so the checksum covers the whole file.
+func (s *source) drain() error {
+	_, err := io.Copy(io.Discard, s.raw)
+	return err
+}
+
+//Closes the decompressor and then the file it reads from.
+func (s *source) Close() error {
+	var err error
+	for _, closer := range s.closers {
+		if cerr := closer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+//Computes the checksum Repository.Checksum reports for a database and its patches without parsing them.
+func sourceChecksum(fsys fs.FS, names ...string) (string, error) {
+	checksum := sha256.New()
+	for _, name := range names {
+		src, err := openSource(fsys, name, checksum)
+		if err != nil {
+			return "", err
+		}
+		err = src.drain()
+		src.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), nil
+}
+
+//Recognizes gzip and zip containers by their magic bytes. Anything else is read as plain xml.
+//A zip archive is read into memory and its xml file is returned, wurfl.xml when there are several.
+func decompress(r io.Reader, name string) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zipMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("wurflgo: reading gzip file %s: %w", name, err)
+		}
+		return gz, gz, nil
+	case bytes.Equal(magic, zipMagic):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("wurflgo: reading zip file %s: %w", name, err)
+		}
+		entry := zipDatabase(archive)
+		if entry == nil {
+			return nil, nil, fmt.Errorf("wurflgo: %s: %w", name, ErrEmptyArchive)
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("wurflgo: reading zip file %s: %w", name, err)
+		}
+		return rc, rc, nil
+	}
+	return br, io.NopCloser(br), nil
+}
+
+func zipDatabase(archive *zip.Reader) *zip.File {
+	var found *zip.File
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || !strings.EqualFold(path.Ext(entry.Name), ".xml") {
+			continue
+		}
+		if strings.EqualFold(path.Base(entry.Name), "wurfl.xml") {
+			return entry
+		}
+		if found == nil {
+			found = entry
+		}
+	}
+	return found
+}
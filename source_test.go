@@ -0,0 +1,135 @@
+package wurflgo
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+//Builds a zip archive with the entries in the given order.
+func zipped(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := archive.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checkTestRepository(t *testing.T, name string, r *Repository) {
+	t.Helper()
+	if got := r.count(); got != 10 {
+		t.Errorf("%s: loaded %d devices, want 10", name, got)
+	}
+	if dev := r.Match(testUAs[6]); dev == nil || dev.Id != "nokia_6600_sub" {
+		t.Errorf("%s: Match(%q) = %v, want nokia_6600_sub", name, testUAs[6], dev)
+	}
+}
+
+func TestLoadCompressed(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "plain", data: []byte(testDatabase)},
+		{name: "gzip", data: gzipped(t, testDatabase)},
+		{name: "zip", data: zipped(t, [2]string{"README.txt", "not xml"}, [2]string{"database.xml", testDatabase})},
+		{name: "zip with several xml files", data: zipped(t,
+			[2]string{"wurfl_patch.xml", "<wurfl_patch>"},
+			[2]string{"data/wurfl.xml", testDatabase},
+			[2]string{"other.xml", "<broken"},
+		)},
+	} {
+		r, err := LoadReader(bytes.NewReader(test.data), "all", WithLogger(discardLogger))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		checkTestRepository(t, test.name, r)
+	}
+}
+
+func TestLoadGzipFile(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "wurfl.xml.gz")
+	data := gzipped(t, testDatabase)
+	if err := os.WriteFile(database, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Load(database, "all", WithLogger(discardLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestRepository(t, "gzip file", r)
+	//The checksum covers the compressed bytes, gzip trailer included.
+	checksum, err := sourceChecksum(nil, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checksum() != checksum {
+		t.Errorf("Checksum() = %s, want %s", r.Checksum(), checksum)
+	}
+}
+
+func TestEmptyArchive(t *testing.T) {
+	data := zipped(t, [2]string{"README.txt", "not xml"}, [2]string{"xml/", ""})
+	_, err := LoadReader(bytes.NewReader(data), "all", WithLogger(discardLogger))
+	if !errors.Is(err, ErrEmptyArchive) {
+		t.Errorf("LoadReader error = %v, want ErrEmptyArchive", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/wurfl.zip":          {Data: zipped(t, [2]string{"wurfl.xml", testDatabase})},
+		"data/wurfl_patch.xml.gz": {Data: gzipped(t, testPatch)},
+	}
+	r, err := LoadFS(fsys, "data/wurfl.zip", "all", WithLogger(discardLogger), WithRetention(RETAIN_ALL), WithPatches("data/wurfl_patch.xml.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestRepository(t, "fs", r)
+	if got := r.DeviceByID("nokia_6600_sub").Capabilities["model_name"]; got != "6600" {
+		t.Errorf("patched model_name = %q, want 6600", got)
+	}
+	checksum, err := sourceChecksum(fsys, "data/wurfl.zip", "data/wurfl_patch.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checksum() != checksum {
+		t.Errorf("Checksum() = %s, want %s", r.Checksum(), checksum)
+	}
+
+	_, err = LoadFS(fsys, "data/missing.xml", "all", WithLogger(discardLogger))
+	var missing *MissingFileError
+	if !errors.As(err, &missing) || missing.Path != "data/missing.xml" {
+		t.Errorf("LoadFS of a missing file: error = %v, want a MissingFileError", err)
+	}
+}
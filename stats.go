@@ -0,0 +1,93 @@
+package wurflgo
+
+import (
+	"sort"
+)
+
+//What a handler received during Initialize. UserAgents is the size of its bucket of normalized user agents,
+//it is smaller than Devices when several user agents normalize to the same string.
+type HandlerStats struct {
+	Handler    string `json:"handler"`
+	Devices    int    `json:"devices"`
+	UserAgents int    `json:"user_agents"`
+}
+
+//Implemented by the handlers of this package, which count the devices they receive in Filter.
+type filterCounter interface {
+	filteredDevices() int
+}
+
+type Stats struct {
+	Devices           int            `json:"devices"`
+	ActualDeviceRoots int            `json:"actual_device_roots"`
+	ByBrand           map[string]int `json:"by_brand"`
+	ByOS              map[string]int `json:"by_os"`
+	//In the order of the matching chain. The devices of all handlers add up to Devices.
+	Handlers []HandlerStats `json:"handlers"`
+	//Devices that no specific handler claimed and landed in the CatchAllHandler.
+	CatchAll int `json:"catch_all"`
+	//The number of devices with a non empty value, per retained capability and device property.
+	Coverage map[string]int `json:"coverage"`
+}
+
+func (r *Repository) Stats() *Stats {
+	stats := &Stats{
+		Devices:  len(r.devices),
+		ByBrand:  make(map[string]int),
+		ByOS:     make(map[string]int),
+		Handlers: []HandlerStats{},
+		Coverage: make(map[string]int),
+	}
+	for _, dev := range r.devices {
+		if dev.ActualDeviceRoot {
+			stats.ActualDeviceRoots++
+		}
+		brand, _ := dev.Capability("brand_name")
+		stats.ByBrand[brand]++
+		os, _ := dev.Capability("device_os")
+		stats.ByOS[os]++
+		for name, value := range dev.Capabilities {
+			if value != "" {
+				stats.Coverage[name]++
+			}
+		}
+		if dev.Properties == nil {
+			continue
+		}
+		for _, name := range PropertyNames {
+			if _, retained := dev.Capabilities[name]; retained {
+				continue
+			}
+			if value, _ := dev.Properties.get(name); value != "" {
+				stats.Coverage[name]++
+			}
+		}
+	}
+	for _, h := range r.chain.Handlers {
+		handler := HandlerStats{Handler: handlerName(h), UserAgents: len(h.GetOrderedUAS())}
+		if counter, ok := h.(filterCounter); ok {
+			handler.Devices = counter.filteredDevices()
+		}
+		if cah, ok := h.(*CatchAllHandler); ok {
+			handler.UserAgents += len(cah.getMozilla4OrderedUAS()) + len(cah.getMozilla5OrderedUAS())
+			stats.CatchAll = handler.Devices
+		}
+		stats.Handlers = append(stats.Handlers, handler)
+	}
+	return stats
+}
+
+//Returns the keys of a count map ordered by descending count, then by key.
+func SortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
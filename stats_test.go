@@ -0,0 +1,45 @@
+package wurflgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatsHandlersAddUpToDevices(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	stats := r.Stats()
+	total := 0
+	byHandler := make(map[string]HandlerStats)
+	for _, h := range stats.Handlers {
+		total += h.Devices
+		byHandler[h.Handler] = h
+		if h.UserAgents > h.Devices {
+			t.Errorf("%s has %d user agents for %d devices", h.Handler, h.UserAgents, h.Devices)
+		}
+	}
+	if total != stats.Devices {
+		t.Errorf("handlers received %d devices, want %d: %+v", total, stats.Devices, stats.Handlers)
+	}
+	if stats.CatchAll != byHandler["CatchAllHandler"].Devices || stats.CatchAll == 0 {
+		t.Errorf("CatchAll = %d, CatchAllHandler received %d", stats.CatchAll, byHandler["CatchAllHandler"].Devices)
+	}
+	if nokia := byHandler["NokiaHandler"]; nokia.Devices != 2 || nokia.UserAgents != 2 {
+		t.Errorf("NokiaHandler = %+v, want 2 devices and user agents", nokia)
+	}
+}
+
+//The serial number is normalized away, so both Nokia 6600 user agents end up in one bucket.
+func TestStatsReportsNormalizationCollisions(t *testing.T) {
+	database := strings.Replace(testDatabase, `</devices>`, `<device id="nokia_6600_sub2" user_agent="Nokia6600/2.0/SN354123456789012 (4.09.1) SymbianOS/7.0s Series60/2.0" fall_back="nokia_generic_series60">
+</device>
+</devices>`, 1)
+	r, err := LoadReader(strings.NewReader(database), "all", WithLogger(discardLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range r.Stats().Handlers {
+		if h.Handler == "NokiaHandler" && (h.Devices != 3 || h.UserAgents != 2) {
+			t.Errorf("NokiaHandler = %+v, want 3 devices in 2 user agents", h)
+		}
+	}
+}
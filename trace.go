@@ -0,0 +1,168 @@
+package wurflgo
+
+import (
+	"reflect"
+
+	"github.com/iain17/wurflgo/levenshtein"
+)
+
+//The step of a handler's ApplyMatch that produced the device id.
+type MatchStage string
+
+const (
+	STAGE_NONE               MatchStage = "none"
+	STAGE_EXACT              MatchStage = "exact"
+	STAGE_CONCLUSIVE         MatchStage = "conclusive"
+	STAGE_RECOVERY           MatchStage = "recovery"
+	STAGE_RECOVERY_CATCH_ALL MatchStage = "recovery_catch_all"
+)
+
+//The algorithm a handler used to pick the reference user agent.
+type MatchMethod string
+
+const (
+	METHOD_RIS MatchMethod = "ris"
+	METHOD_LD  MatchMethod = "ld"
+)
+
+//MatchTrace explains how a user agent was matched.
+//MatchedUA is the normalized reference user agent the device id was taken from, as it is stored in the handler's index.
+//It is only set when a reference user agent was actually matched: by the exact stage, or by a RIS or LD search
+//in the conclusive or recovery stages. Many recovery stages return a hard-coded device id instead, and then
+//MatchedUA, Method, Tolerance, PrefixLength and Distance are left empty.
+//Tolerance is the tolerance the handler passed to the RIS or LD search that found MatchedUA: the minimum
+//common prefix length for RIS and the maximum Levenshtein distance for LD. PrefixLength is the number of
+//leading characters MatchedUA shares with NormalizedUA and Distance the Levenshtein distance between them.
+type MatchTrace struct {
+	UA           string      `json:"ua"`
+	Handler      string      `json:"handler"`
+	NormalizedUA string      `json:"normalized_ua"`
+	Stage        MatchStage  `json:"stage"`
+	DeviceId     string      `json:"device_id"`
+	MatchedUA    string      `json:"matched_ua,omitempty"`
+	Method       MatchMethod `json:"method,omitempty"`
+	Tolerance    int         `json:"tolerance"`
+	PrefixLength int         `json:"prefix_length"`
+	Distance     int         `json:"distance"`
+}
+
+//Implemented by the built-in handlers. A handler that does not implement it is traced without its tolerances.
+type traceable interface {
+	traced(*matchRecorder) Handlers
+}
+
+//Implemented by the built-in handlers. The stages of a handler that does not implement it are traced with the user agent as is.
+type normalizing interface {
+	GetNormalizer() Normalizer
+}
+
+//Records the RIS and LD searches of a handler while it is traced.
+type matchRecorder struct {
+	method    MatchMethod
+	tolerance int
+	matched   string
+}
+
+//Keeps the last search that found a reference user agent.
+func (r *matchRecorder) record(method MatchMethod, tolerance int, match string) {
+	if match != "" {
+		r.method = method
+		r.tolerance = tolerance
+		r.matched = match
+	}
+}
+
+//util.RISMatch, reporting the search to recorder when the handler is traced.
+func risMatch(recorder *matchRecorder, collection []string, needle string, tolerance int) string {
+	match := util.RISMatch(collection, needle, tolerance)
+	if recorder != nil {
+		recorder.record(METHOD_RIS, tolerance, match)
+	}
+	return match
+}
+
+//util.LDMatch, reporting the search to recorder when the handler is traced.
+func ldMatch(recorder *matchRecorder, collection []string, needle string, tolerance int) string {
+	match := util.LDMatch(collection, needle, tolerance)
+	if recorder != nil {
+		recorder.record(METHOD_LD, tolerance, match)
+	}
+	return match
+}
+
+//Trace runs the same steps as Match but records which handler and stage produced the device id.
+//The stages run on a copy of the handler, so tracing is as safe to call concurrently as Match.
+func (c *Chain) Trace(ua string) *MatchTrace {
+	trace := &MatchTrace{UA: ua, Stage: STAGE_NONE, DeviceId: GENERIC}
+	for _, h := range c.Handlers {
+		if !h.CanHandle(ua) {
+			continue
+		}
+		recorder := new(matchRecorder)
+		traced := h
+		if t, ok := h.(traceable); ok {
+			traced = t.traced(recorder)
+		}
+		trace.Handler = handlerName(h)
+		trace.NormalizedUA = ua
+		if n, ok := h.(normalizing); ok {
+			trace.NormalizedUA = n.GetNormalizer().Normalize(ua)
+		}
+		stages := []struct {
+			stage MatchStage
+			apply func(string) string
+		}{
+			{STAGE_EXACT, traced.ApplyExactMatch},
+			{STAGE_CONCLUSIVE, traced.ApplyConclusiveMatch},
+			{STAGE_RECOVERY, traced.ApplyRecoveryMatch},
+			{STAGE_RECOVERY_CATCH_ALL, traced.ApplyRecoveryCatchAllMatch},
+		}
+		for _, s := range stages {
+			*recorder = matchRecorder{}
+			trace.Stage = s.stage
+			trace.DeviceId = s.apply(trace.NormalizedUA)
+			if !h.IsBlankOrGeneric(trace.DeviceId) {
+				trace.setMatched(recorder)
+				return trace
+			}
+		}
+		if h.IsBlankOrGeneric(trace.NormalizedUA) {
+			trace.DeviceId = GENERIC
+		}
+		return trace
+	}
+	return trace
+}
+
+func (trace *MatchTrace) setMatched(recorder *matchRecorder) {
+	switch {
+	case trace.Stage == STAGE_EXACT:
+		trace.MatchedUA = trace.NormalizedUA
+	case recorder.matched != "":
+		trace.MatchedUA = recorder.matched
+		trace.Method = recorder.method
+		trace.Tolerance = recorder.tolerance
+	default:
+		return
+	}
+	trace.PrefixLength = commonPrefixLength(trace.NormalizedUA, trace.MatchedUA)
+	trace.Distance = levenshtein.LD(trace.NormalizedUA, trace.MatchedUA)
+}
+
+//MatchWithTrace returns the same device as Match together with an explanation of how it was found.
+func (r *Repository) MatchWithTrace(ua string) (*Device, *MatchTrace) {
+	trace := r.chain.Trace(ua)
+	return r.find(trace.DeviceId), trace
+}
+
+func handlerName(h Handlers) string {
+	return reflect.TypeOf(h).Elem().Name()
+}
+
+func commonPrefixLength(s, t string) int {
+	i := 0
+	for i < len(s) && i < len(t) && s[i] == t[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,57 @@
+package wurflgo
+
+import "testing"
+
+func TestMatchWithTrace(t *testing.T) {
+	r := loadTestRepository(t, "all")
+	tests := []struct {
+		ua        string
+		stage     MatchStage
+		deviceId  string
+		method    MatchMethod
+		matchedUA string
+	}{
+		{testUAs[6], STAGE_EXACT, "nokia_6600_sub", "", testUAs[6]},
+		{"Nokia6600/2.0 (4.09.7) SymbianOS/7.0s Series60/2.0", STAGE_CONCLUSIVE, "nokia_6600_sub", METHOD_RIS, testUAs[6]},
+		{"Mozilla/5.0 (iPod; CPU iPhone OS 12_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", STAGE_RECOVERY, "apple_ipod_touch_ver1", "", ""},
+	}
+	for _, test := range tests {
+		_, trace := r.MatchWithTrace(test.ua)
+		if trace.Stage != test.stage || trace.DeviceId != test.deviceId || trace.Method != test.method || trace.MatchedUA != test.matchedUA {
+			t.Errorf("MatchWithTrace(%q) = %+v", test.ua, trace)
+			continue
+		}
+		switch {
+		case test.method == METHOD_RIS && (trace.Tolerance <= 0 || trace.PrefixLength < trace.Tolerance):
+			t.Errorf("MatchWithTrace(%q): prefix length %d below tolerance %d", test.ua, trace.PrefixLength, trace.Tolerance)
+		case test.matchedUA == "" && (trace.Tolerance != 0 || trace.PrefixLength != 0 || trace.Distance != 0):
+			t.Errorf("MatchWithTrace(%q): scores set without a reference user agent: %+v", test.ua, trace)
+		}
+	}
+}
+
+//A handler written outside this package, with none of the optional interfaces of the built-in handlers.
+type externalHandler struct{}
+
+func (h *externalHandler) SetNextHandler(Handlers)                  {}
+func (h *externalHandler) CanHandle(ua string) bool                 { return true }
+func (h *externalHandler) Filter(string, string)                    {}
+func (h *externalHandler) Match(ua string) string                   { return h.ApplyMatch(ua) }
+func (h *externalHandler) ApplyMatch(ua string) string              { return h.ApplyExactMatch(ua) }
+func (h *externalHandler) ApplyExactMatch(ua string) string         { return "external_" + ua }
+func (h *externalHandler) ApplyConclusiveMatch(string) string       { return GENERIC }
+func (h *externalHandler) LookForMatchingUA(string) string          { return "" }
+func (h *externalHandler) ApplyRecoveryMatch(string) string         { return GENERIC }
+func (h *externalHandler) ApplyRecoveryCatchAllMatch(string) string { return GENERIC }
+func (h *externalHandler) GetDeviceIdFromRIS(string, int) string    { return GENERIC }
+func (h *externalHandler) GetDeviceIdFromLD(string, int) string     { return GENERIC }
+func (h *externalHandler) IsBlankOrGeneric(id string) bool          { return id == "" || id == GENERIC }
+func (h *externalHandler) GetOrderedUAS() []string                  { return nil }
+
+func TestTraceExternalHandler(t *testing.T) {
+	chain := NewChain().AddHandler(&externalHandler{})
+	trace := chain.Trace("Custom/1.0")
+	if trace.Handler != "externalHandler" || trace.NormalizedUA != "Custom/1.0" || trace.Stage != STAGE_EXACT || trace.DeviceId != "external_Custom/1.0" {
+		t.Errorf("Trace = %+v", trace)
+	}
+}
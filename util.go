@@ -12,9 +12,6 @@ type Util struct{
 	SmartTVBrowsers []string
 	DesktopBrowsers []string
 	MobileCatchAllIds map[string]string
-	isDesktopBrowser int
-	isMobileBrowser int
-	isSmartTV int 
 }
 
 func NewUtil() *Util{
@@ -142,6 +139,10 @@ func NewUtil() *Util{
 	}
 }
 
+//Deprecated: the browser checks no longer cache their result, so there is nothing to reset.
+func (u *Util) Reset() {
+}
+
 func (u *Util) RemoveLocale(ua string) string{
 	wordRx := regexp.MustCompile(`; ?[a-z]{2}(?:-[a-zA-Z]{2})?(?:\.utf8|\.big5)?\b-?`)
 	return wordRx.ReplaceAllString(ua,`; xx-xx`)
@@ -224,55 +225,17 @@ func (u *Util) IndexOfAnyOrLength(ua string, needles []string,startIndex int) in
 
 }
 
-func (u *Util) Reset(){
-	u.isSmartTV = 0
-	u.isMobileBrowser = 0
-	u.isDesktopBrowser = 0
-}
-
+//The classifiers below keep no state between calls, so a single Util can be shared by concurrent matches.
 func (u *Util) IsMobileBrowser(ua string) bool{
-	if u.isMobileBrowser != 0{
-		return u.isMobileBrowser == 1
-	}
-	u.isMobileBrowser = -1
-	ua = strings.ToLower(ua)
-	for i := range u.MobileBrowsers{
-		if strings.Index(ua,u.MobileBrowsers[i]) != -1{
-			u.isMobileBrowser = 1
-			break
-		}
-	}
-	return u.isMobileBrowser == 1
+	return u.CheckIfContainsAnyOf(strings.ToLower(ua), u.MobileBrowsers)
 }
 
 func (u *Util) IsDesktopBrowser(ua string) bool{
-	if u.isDesktopBrowser != 0{
-		return u.isDesktopBrowser == 1
-	}
-	u.isDesktopBrowser = -1
-	ua = strings.ToLower(ua)
-	for i := range u.DesktopBrowsers{
-		if strings.Index(ua,u.DesktopBrowsers[i]) != -1{
-			u.isDesktopBrowser = 1
-			break
-		}
-	}
-	return u.isDesktopBrowser == 1
+	return u.CheckIfContainsAnyOf(strings.ToLower(ua), u.DesktopBrowsers)
 }
 
 func (u *Util) IsSmartTV(ua string) bool{
-	if u.isSmartTV != 0{
-		return u.isSmartTV == 1
-	}
-	u.isSmartTV = -1
-	ua = strings.ToLower(ua)
-	for i := range u.SmartTVBrowsers{
-		if strings.Index(ua,u.SmartTVBrowsers[i]) != -1{
-			u.isSmartTV = 1
-			break
-		}
-	}
-	return u.isSmartTV == 1
+	return u.CheckIfContainsAnyOf(strings.ToLower(ua), u.SmartTVBrowsers)
 }
 
 func (u *Util) GetMobileCatchAllId(ua string) string{
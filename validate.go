@@ -0,0 +1,203 @@
+package wurflgo
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+//The kinds of problems Validate reports.
+type IssueKind string
+
+const (
+	ISSUE_DUPLICATE_ID         IssueKind = "duplicate_id"
+	ISSUE_DUPLICATE_UA         IssueKind = "duplicate_user_agent"
+	ISSUE_EMPTY_UA             IssueKind = "empty_user_agent"
+	ISSUE_UNKNOWN_FALLBACK     IssueKind = "unknown_fall_back"
+	ISSUE_CYCLE                IssueKind = "cycle"
+	ISSUE_MISSING_GENERIC      IssueKind = "missing_generic"
+	ISSUE_UNDEFINED_GROUP      IssueKind = "undefined_group"
+	ISSUE_UNDEFINED_CAPABILITY IssueKind = "undefined_capability"
+	ISSUE_DO_NOT_MATCH         IssueKind = "do_not_match"
+)
+
+type Severity string
+
+const (
+	SEVERITY_ERROR   Severity = "error"
+	SEVERITY_WARNING Severity = "warning"
+)
+
+//Prefix of the user agents of devices that only exist to be fallen back to.
+const DO_NOT_MATCH = "DO_NOT_MATCH"
+
+//A problem found by Validate. Only the fields that apply to its kind are set.
+type Issue struct {
+	Kind       IssueKind `json:"kind"`
+	Severity   Severity  `json:"severity"`
+	Id         string    `json:"id,omitempty"`
+	UA         string    `json:"user_agent,omitempty"`
+	Parent     string    `json:"fall_back,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	Capability string    `json:"capability,omitempty"`
+	Ids        []string  `json:"ids,omitempty"`
+}
+
+//The result of validating a database and its patches. Issues are sorted by device id.
+type ValidationReport struct {
+	Database string   `json:"database"`
+	Patches  []string `json:"patches"`
+	Devices  int      `json:"devices"`
+	Errors   int      `json:"errors"`
+	Warnings int      `json:"warnings"`
+	Issues   []Issue  `json:"issues"`
+}
+
+//Reports whether the database has no errors. Warnings do not make it invalid.
+func (v *ValidationReport) Valid() bool {
+	return v.Errors == 0
+}
+
+//Parses a wurfl xml database, applies the patches given with WithPatches and checks the structure of the
+//result. Devices are not registered, so a database with problems is still checked as a whole.
+//The returned error is only set when the files cannot be read or are not well formed.
+func Validate(database string, options ...Option) (*ValidationReport, error) {
+	wp, err := NewProcessor("all", database, NewRepository())
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		option(wp)
+	}
+	return wp.validate(context.Background())
+}
+
+func (wp *WurflProcessor) validate(ctx context.Context) (*ValidationReport, error) {
+	if wp.source != nil {
+		defer wp.source.Close()
+	}
+	report := &ValidationReport{Database: wp.Name, Patches: append([]string{}, wp.Patches...), Issues: []Issue{}}
+	//Every definition is checked, DeviceList only keeps the last one of a duplicate id and is only used
+	//to resolve fall_backs.
+	devices := []*XMLDevice{}
+	err := wp.decode(ctx, wp.In, wp.Name, func(dev *XMLDevice) error {
+		if _, found := wp.DeviceList[dev.Id]; found {
+			report.add(Issue{Kind: ISSUE_DUPLICATE_ID, Severity: SEVERITY_ERROR, Id: dev.Id})
+		}
+		wp.DeviceList[dev.Id] = dev
+		devices = append(devices, dev)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, patch := range wp.Patches {
+		patchReport, err := wp.applyPatch(ctx, patch)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range patchReport.Added {
+			devices = append(devices, wp.DeviceList[id])
+		}
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].Id < devices[j].Id
+	})
+	ids := make([]string, 0, len(wp.DeviceList))
+	for id := range wp.DeviceList {
+		ids = append(ids, id)
+	}
+	report.Devices = len(ids)
+
+	wp.validateUserAgents(report, devices)
+	wp.validateFallBacks(report, devices, ids)
+	wp.validateCapabilities(report, devices)
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Id < report.Issues[j].Id
+	})
+	return report, nil
+}
+
+func (v *ValidationReport) add(issue Issue) {
+	if issue.Severity == SEVERITY_ERROR {
+		v.Errors++
+	} else {
+		v.Warnings++
+	}
+	v.Issues = append(v.Issues, issue)
+}
+
+func (wp *WurflProcessor) validateUserAgents(report *ValidationReport, devices []*XMLDevice) {
+	owners := make(map[string]string)
+	for _, dev := range devices {
+		switch ua := dev.UserAgent; {
+		case ua == "":
+			if dev.Id != GENERIC {
+				report.add(Issue{Kind: ISSUE_EMPTY_UA, Severity: SEVERITY_ERROR, Id: dev.Id})
+			}
+			continue
+		case strings.HasPrefix(ua, DO_NOT_MATCH):
+			report.add(Issue{Kind: ISSUE_DO_NOT_MATCH, Severity: SEVERITY_WARNING, Id: dev.Id, UA: ua})
+		}
+		if owner, found := owners[dev.UserAgent]; found {
+			//Two definitions of the same id are already reported as a duplicate id.
+			if owner != dev.Id {
+				report.add(Issue{Kind: ISSUE_DUPLICATE_UA, Severity: SEVERITY_ERROR, Id: dev.Id, UA: dev.UserAgent, Ids: []string{owner, dev.Id}})
+			}
+			continue
+		}
+		owners[dev.UserAgent] = dev.Id
+	}
+}
+
+func (wp *WurflProcessor) validateFallBacks(report *ValidationReport, devices []*XMLDevice, ids []string) {
+	for _, dev := range devices {
+		parent := dev.Parent
+		if (parent == "" && dev.Id != GENERIC) || (parent != "" && wp.DeviceList[parent] == nil) {
+			if parent == "" {
+				parent = "root"
+			}
+			report.add(Issue{Kind: ISSUE_UNKNOWN_FALLBACK, Severity: SEVERITY_ERROR, Id: dev.Id, Parent: parent})
+		}
+	}
+	for _, problem := range wp.findFallBackProblems(ids) {
+		if problem.Problem == PROBLEM_CYCLE {
+			report.add(Issue{Kind: ISSUE_CYCLE, Severity: SEVERITY_ERROR, Id: problem.Id, Parent: problem.Parent, Ids: problem.Cycle})
+		}
+	}
+}
+
+//Every group and capability a device sets has to be defined by generic, the root every capability falls back to.
+func (wp *WurflProcessor) validateCapabilities(report *ValidationReport, devices []*XMLDevice) {
+	generic := wp.DeviceList[GENERIC]
+	if generic == nil {
+		report.add(Issue{Kind: ISSUE_MISSING_GENERIC, Severity: SEVERITY_ERROR, Id: GENERIC})
+		return
+	}
+	defined := make(map[string]map[string]bool)
+	for _, grp := range generic.Group {
+		if defined[grp.Id] == nil {
+			defined[grp.Id] = make(map[string]bool)
+		}
+		for _, capability := range grp.Capabilities {
+			defined[grp.Id][capability.Name] = true
+		}
+	}
+	for _, dev := range devices {
+		if dev == generic {
+			continue
+		}
+		for _, grp := range dev.Group {
+			capabilities, found := defined[grp.Id]
+			if !found {
+				report.add(Issue{Kind: ISSUE_UNDEFINED_GROUP, Severity: SEVERITY_ERROR, Id: dev.Id, Group: grp.Id})
+				continue
+			}
+			for _, capability := range grp.Capabilities {
+				if !capabilities[capability.Name] {
+					report.add(Issue{Kind: ISSUE_UNDEFINED_CAPABILITY, Severity: SEVERITY_ERROR, Id: dev.Id, Group: grp.Id, Capability: capability.Name})
+				}
+			}
+		}
+	}
+}
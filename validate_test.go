@@ -0,0 +1,56 @@
+package wurflgo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateChecksEveryDefinition(t *testing.T) {
+	dir := t.TempDir()
+	database := filepath.Join(dir, "wurfl.xml")
+	duplicate := `<device id="nokia_6600_sub" user_agent="" fall_back="unknown_parent">
+  <group id="product_info">
+    <capability name="bogus" value="true"/>
+  </group>
+</device>
+<device id="nokia_6600_sub"`
+	data := strings.Replace(testDatabase, `<device id="nokia_6600_sub"`, duplicate, 1)
+	if err := os.WriteFile(database, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patch := filepath.Join(dir, "patch.xml")
+	if err := os.WriteFile(patch, []byte(`<wurfl_patch><devices>
+<device id="patched_device" user_agent="Nokia6600/1.0" fall_back="generic">
+  <group id="undefined_group"/>
+</device>
+</devices></wurfl_patch>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Validate(database, WithPatches(patch), WithLogger(discardLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[IssueKind]Issue)
+	for _, issue := range report.Issues {
+		if issue.Severity == SEVERITY_ERROR {
+			found[issue.Kind] = issue
+		}
+	}
+	for kind, id := range map[IssueKind]string{
+		ISSUE_DUPLICATE_ID:         "nokia_6600_sub",
+		ISSUE_EMPTY_UA:             "nokia_6600_sub",
+		ISSUE_UNKNOWN_FALLBACK:     "nokia_6600_sub",
+		ISSUE_UNDEFINED_CAPABILITY: "nokia_6600_sub",
+		ISSUE_DUPLICATE_UA:         "patched_device",
+		ISSUE_UNDEFINED_GROUP:      "patched_device",
+	} {
+		if issue, ok := found[kind]; !ok || issue.Id != id {
+			t.Errorf("%s issue = %+v, want one for %s", kind, issue, id)
+		}
+	}
+	if report.Errors != 6 || report.Valid() {
+		t.Errorf("Errors = %d, want 6: %+v", report.Errors, report.Issues)
+	}
+}
@@ -0,0 +1,235 @@
+package wurflgo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//A virtual capability is computed from the static capabilities of the matched device and the user agent of the request.
+//Capabilities that were not retained by the repository are treated as empty, so most virtual capabilities
+//need RETAIN_ALL or a whitelist covering the product_info, display and markup capabilities they read.
+type VirtualCapability func(dev *Device, ua string) string
+
+var botHandler = NewBotCrawlerTranscoderHandler(new(Null))
+
+var virtualCapabilities = map[string]VirtualCapability{
+	"is_android":                   virtualIsOs("Android"),
+	"is_ios":                       virtualIsOs("iOS"),
+	"is_windows_phone":             virtualIsOs("Windows Phone"),
+	"is_mobile":                    virtualFlag("is_wireless_device"),
+	"is_full_desktop":              virtualFlag("ux_full_desktop"),
+	"is_touchscreen":               virtualIsTouchscreen,
+	"is_smartphone":                virtualIsSmartphone,
+	"is_robot":                     virtualIsRobot,
+	"is_html_preferred":            virtualMarkupPrefix("html_web"),
+	"is_xhtmlmp_preferred":         virtualMarkupPrefix("html_wi"),
+	"is_wml_preferred":             virtualMarkupPrefix("wml"),
+	"form_factor":                  virtualFormFactor,
+	"advertised_browser":           virtualAdvertisedBrowser,
+	"advertised_browser_version":   virtualAdvertisedBrowserVersion,
+	"advertised_device_os":         virtualAdvertisedDeviceOs,
+	"advertised_device_os_version": virtualAdvertisedDeviceOsVersion,
+	"complete_device_name":         virtualCompleteDeviceName,
+	"device_name":                  virtualDeviceName,
+}
+
+//Returns the names of all virtual capabilities in alphabetical order.
+func VirtualCapabilityNames() []string {
+	names := make([]string, 0, len(virtualCapabilities))
+	for name := range virtualCapabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Computes a virtual capability for this device as matched from ua.
+func (dev *Device) VirtualCapability(name, ua string) (string, error) {
+	capability, found := virtualCapabilities[name]
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCapability, name)
+	}
+	return capability(dev, ua), nil
+}
+
+//Computes every virtual capability for this device as matched from ua.
+func (dev *Device) VirtualCapabilities(ua string) map[string]string {
+	values := make(map[string]string, len(virtualCapabilities))
+	for name, capability := range virtualCapabilities {
+		values[name] = capability(dev, ua)
+	}
+	return values
+}
+
+func (dev *Device) capabilityValue(name string) string {
+	value, _ := dev.Capability(name)
+	return value
+}
+
+func (dev *Device) flag(name string) bool {
+	return dev.capabilityValue(name) == "true"
+}
+
+func virtualFlag(name string) VirtualCapability {
+	return func(dev *Device, ua string) string {
+		return strconv.FormatBool(dev.flag(name))
+	}
+}
+
+func virtualIsOs(os string) VirtualCapability {
+	return func(dev *Device, ua string) string {
+		return strconv.FormatBool(dev.capabilityValue("device_os") == os)
+	}
+}
+
+func virtualMarkupPrefix(prefix string) VirtualCapability {
+	return func(dev *Device, ua string) string {
+		return strconv.FormatBool(strings.HasPrefix(dev.capabilityValue("preferred_markup"), prefix))
+	}
+}
+
+func virtualIsTouchscreen(dev *Device, ua string) string {
+	return strconv.FormatBool(dev.capabilityValue("pointing_method") == "touchscreen")
+}
+
+var smartphoneOses = []string{"Android", "iOS", "Windows Phone", "RIM OS", "BlackBerry OS", "Hp webOS", "Firefox OS", "Tizen", "Ubuntu Touch"}
+
+func isSmartphone(dev *Device) bool {
+	if !dev.flag("is_wireless_device") || dev.flag("is_tablet") {
+		return false
+	}
+	if dev.capabilityValue("pointing_method") != "touchscreen" {
+		return false
+	}
+	width, err := strconv.Atoi(dev.capabilityValue("resolution_width"))
+	if err != nil || width < 320 {
+		return false
+	}
+	os := dev.capabilityValue("device_os")
+	for _, smartphoneOs := range smartphoneOses {
+		if os == smartphoneOs {
+			return true
+		}
+	}
+	return false
+}
+
+func virtualIsSmartphone(dev *Device, ua string) string {
+	return strconv.FormatBool(isSmartphone(dev))
+}
+
+func virtualIsRobot(dev *Device, ua string) string {
+	return strconv.FormatBool(botHandler.CanHandle(ua))
+}
+
+func virtualFormFactor(dev *Device, ua string) string {
+	switch {
+	case botHandler.CanHandle(ua):
+		return "Robot"
+	case dev.flag("ux_full_desktop"):
+		return "Desktop"
+	case dev.flag("is_smarttv"):
+		return "Smart-TV"
+	case !dev.flag("is_wireless_device"):
+		return "Other Non-Mobile"
+	case dev.flag("is_tablet"):
+		return "Tablet"
+	case isSmartphone(dev):
+		return "Smartphone"
+	case dev.flag("can_assign_phone_number"):
+		return "Feature Phone"
+	}
+	return "Other Mobile"
+}
+
+type uaPattern struct {
+	name string
+	rx   *regexp.Regexp
+}
+
+//Checked in order, the first pattern found in the user agent names the browser and captures its version.
+var advertisedBrowsers = []uaPattern{
+	{"Edge", regexp.MustCompile(`\bEdge?/(\d+(?:\.\d+)?)`)},
+	{"Opera", regexp.MustCompile(`OPR/(\d+(?:\.\d+)?)`)},
+	{"Opera Mini", regexp.MustCompile(`Opera Mini/(\d+(?:\.\d+)?)`)},
+	{"Opera Mobile", regexp.MustCompile(`Opera Mobi.*Version/(\d+(?:\.\d+)?)`)},
+	{"Opera", regexp.MustCompile(`Opera.*Version/(\d+(?:\.\d+)?)`)},
+	{"Samsung Browser", regexp.MustCompile(`SamsungBrowser/(\d+(?:\.\d+)?)`)},
+	{"UC Browser", regexp.MustCompile(`UC ?Browser/(\d+(?:\.\d+)?)`)},
+	{"Firefox", regexp.MustCompile(`Firefox/(\d+(?:\.\d+)?)`)},
+	{"Chrome Mobile", regexp.MustCompile(`Android.*Chrome/(\d+(?:\.\d+)?).*Mobile`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/(\d+(?:\.\d+)?)`)},
+	{"IEMobile", regexp.MustCompile(`IEMobile/(\d+(?:\.\d+)?)`)},
+	{"Internet Explorer", regexp.MustCompile(`MSIE (\d+(?:\.\d+)?)`)},
+	{"Internet Explorer", regexp.MustCompile(`Trident/.*rv:(\d+(?:\.\d+)?)`)},
+	{"Android Webkit", regexp.MustCompile(`Android.*Version/(\d+(?:\.\d+)?).*Safari`)},
+	{"Mobile Safari", regexp.MustCompile(`Version/(\d+(?:\.\d+)?).*Mobile.*Safari`)},
+	{"Safari", regexp.MustCompile(`Version/(\d+(?:\.\d+)?).*Safari`)},
+}
+
+var advertisedOses = []uaPattern{
+	{"Windows Phone", regexp.MustCompile(`Windows Phone(?: OS)? (\d+(?:\.\d+)?)`)},
+	{"Android", regexp.MustCompile(`Android (\d+(?:\.\d+)?)`)},
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*? OS (\d+(?:_\d+)?)`)},
+	{"Mac OS X", regexp.MustCompile(`Mac OS X (\d+(?:[_.]\d+)?)`)},
+	{"Chrome OS", regexp.MustCompile(`CrOS \S+ (\d+(?:\.\d+)?)`)},
+	{"Windows", regexp.MustCompile(`Windows NT (\d+\.\d+)`)},
+	{"BlackBerry OS", regexp.MustCompile(`BlackBerry.*Version/(\d+(?:\.\d+)?)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+func findAdvertised(patterns []uaPattern, ua string) (string, string) {
+	for _, p := range patterns {
+		if matches := p.rx.FindStringSubmatch(ua); matches != nil {
+			return p.name, strings.Replace(matches[1], "_", ".", -1)
+		}
+	}
+	return "", ""
+}
+
+func virtualAdvertisedBrowser(dev *Device, ua string) string {
+	if browser, _ := findAdvertised(advertisedBrowsers, ua); browser != "" {
+		return browser
+	}
+	return dev.capabilityValue("mobile_browser")
+}
+
+func virtualAdvertisedBrowserVersion(dev *Device, ua string) string {
+	if browser, version := findAdvertised(advertisedBrowsers, ua); browser != "" {
+		return version
+	}
+	return dev.capabilityValue("mobile_browser_version")
+}
+
+func virtualAdvertisedDeviceOs(dev *Device, ua string) string {
+	if os, _ := findAdvertised(advertisedOses, ua); os != "" {
+		return os
+	}
+	return dev.capabilityValue("device_os")
+}
+
+func virtualAdvertisedDeviceOsVersion(dev *Device, ua string) string {
+	if os, version := findAdvertised(advertisedOses, ua); os != "" {
+		return version
+	}
+	return dev.capabilityValue("device_os_version")
+}
+
+func virtualCompleteDeviceName(dev *Device, ua string) string {
+	name := strings.TrimSpace(dev.capabilityValue("brand_name") + " " + dev.capabilityValue("model_name"))
+	if marketingName := dev.capabilityValue("marketing_name"); marketingName != "" {
+		name += " (" + marketingName + ")"
+	}
+	return strings.TrimSpace(name)
+}
+
+func virtualDeviceName(dev *Device, ua string) string {
+	name := dev.capabilityValue("marketing_name")
+	if name == "" {
+		name = dev.capabilityValue("model_name")
+	}
+	return strings.TrimSpace(dev.capabilityValue("brand_name") + " " + name)
+}
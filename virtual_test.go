@@ -0,0 +1,200 @@
+package wurflgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const (
+	chromeAndroidUA = "Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.117 Mobile Safari/537.36"
+	googlebotUA     = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+func TestVirtualCapabilityFlags(t *testing.T) {
+	smartphone := map[string]string{
+		"is_wireless_device": "true",
+		"is_tablet":          "false",
+		"pointing_method":    "touchscreen",
+		"resolution_width":   "1080",
+		"device_os":          "Android",
+	}
+	smartphoneWith := func(name string, value string) map[string]string {
+		capabilities := make(map[string]string)
+		for k, v := range smartphone {
+			capabilities[k] = v
+		}
+		capabilities[name] = value
+		return capabilities
+	}
+	for _, test := range []struct {
+		capability   string
+		capabilities map[string]string
+		want         string
+	}{
+		{capability: "is_android", capabilities: smartphone, want: "true"},
+		{capability: "is_android", capabilities: map[string]string{"device_os": "iOS"}, want: "false"},
+		{capability: "is_ios", capabilities: map[string]string{"device_os": "iOS"}, want: "true"},
+		{capability: "is_ios", capabilities: smartphone, want: "false"},
+		{capability: "is_windows_phone", capabilities: map[string]string{"device_os": "Windows Phone"}, want: "true"},
+		{capability: "is_windows_phone", capabilities: map[string]string{"device_os": "Windows"}, want: "false"},
+		{capability: "is_mobile", capabilities: smartphone, want: "true"},
+		{capability: "is_mobile", capabilities: map[string]string{"is_wireless_device": "false"}, want: "false"},
+		{capability: "is_mobile", capabilities: map[string]string{}, want: "false"},
+		{capability: "is_full_desktop", capabilities: map[string]string{"ux_full_desktop": "true"}, want: "true"},
+		{capability: "is_full_desktop", capabilities: smartphone, want: "false"},
+		{capability: "is_touchscreen", capabilities: smartphone, want: "true"},
+		{capability: "is_touchscreen", capabilities: map[string]string{"pointing_method": "joystick"}, want: "false"},
+		{capability: "is_smartphone", capabilities: smartphone, want: "true"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("is_tablet", "true"), want: "false"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("is_wireless_device", "false"), want: "false"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("pointing_method", "stylus"), want: "false"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("resolution_width", "240"), want: "false"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("resolution_width", ""), want: "false"},
+		{capability: "is_smartphone", capabilities: smartphoneWith("device_os", "Symbian OS"), want: "false"},
+		{capability: "is_html_preferred", capabilities: map[string]string{"preferred_markup": "html_web_4_0"}, want: "true"},
+		{capability: "is_html_preferred", capabilities: map[string]string{"preferred_markup": "html_wi_oma_xhtmlmp_1_0"}, want: "false"},
+		{capability: "is_xhtmlmp_preferred", capabilities: map[string]string{"preferred_markup": "html_wi_oma_xhtmlmp_1_0"}, want: "true"},
+		{capability: "is_xhtmlmp_preferred", capabilities: map[string]string{"preferred_markup": "wml_1_3"}, want: "false"},
+		{capability: "is_wml_preferred", capabilities: map[string]string{"preferred_markup": "wml_1_3"}, want: "true"},
+		{capability: "is_wml_preferred", capabilities: map[string]string{"preferred_markup": "html_web_4_0"}, want: "false"},
+	} {
+		dev := &Device{Id: "test", Capabilities: test.capabilities}
+		got, err := dev.VirtualCapability(test.capability, chromeAndroidUA)
+		if err != nil {
+			t.Errorf("VirtualCapability(%q): %v", test.capability, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s with %v = %q, want %q", test.capability, test.capabilities, got, test.want)
+		}
+	}
+}
+
+func TestVirtualIsRobot(t *testing.T) {
+	dev := &Device{Id: "test", Capabilities: map[string]string{}}
+	for ua, want := range map[string]string{
+		googlebotUA:     "true",
+		chromeAndroidUA: "false",
+	} {
+		if got, _ := dev.VirtualCapability("is_robot", ua); got != want {
+			t.Errorf("is_robot for %q = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestVirtualFormFactor(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		capabilities map[string]string
+		ua           string
+		want         string
+	}{
+		{name: "robot", capabilities: map[string]string{"is_wireless_device": "true"}, ua: googlebotUA, want: "Robot"},
+		{name: "desktop", capabilities: map[string]string{"ux_full_desktop": "true"}, want: "Desktop"},
+		{name: "smart tv", capabilities: map[string]string{"is_smarttv": "true"}, want: "Smart-TV"},
+		{name: "other non-mobile", capabilities: map[string]string{"is_wireless_device": "false"}, want: "Other Non-Mobile"},
+		{name: "tablet", capabilities: map[string]string{"is_wireless_device": "true", "is_tablet": "true"}, want: "Tablet"},
+		{name: "smartphone", capabilities: map[string]string{"is_wireless_device": "true", "pointing_method": "touchscreen", "resolution_width": "720", "device_os": "iOS"}, want: "Smartphone"},
+		{name: "feature phone", capabilities: map[string]string{"is_wireless_device": "true", "can_assign_phone_number": "true"}, want: "Feature Phone"},
+		{name: "other mobile", capabilities: map[string]string{"is_wireless_device": "true"}, want: "Other Mobile"},
+	} {
+		dev := &Device{Id: "test", Capabilities: test.capabilities}
+		ua := test.ua
+		if ua == "" {
+			ua = chromeAndroidUA
+		}
+		if got, _ := dev.VirtualCapability("form_factor", ua); got != test.want {
+			t.Errorf("%s: form_factor = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVirtualAdvertised(t *testing.T) {
+	dev := &Device{Id: "test", Capabilities: map[string]string{
+		"mobile_browser":         "Access NetFront",
+		"mobile_browser_version": "3.2",
+		"device_os":              "Symbian OS",
+		"device_os_version":      "7.0",
+	}}
+	for _, test := range []struct {
+		ua                      string
+		browser, browserVersion string
+		os, osVersion           string
+	}{
+		{ua: chromeAndroidUA, browser: "Chrome Mobile", browserVersion: "109.0", os: "Android", osVersion: "12"},
+		{ua: "Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/19.0 Chrome/102.0.5005.125 Mobile Safari/537.36", browser: "Samsung Browser", browserVersion: "19.0", os: "Android", osVersion: "12"},
+		{ua: "Mozilla/5.0 (Linux; U; Android 2.3.3; xx-xx; GT-I9100 Build/GINGERBREAD) AppleWebKit/533.1 (KHTML, like Gecko) Version/4.0 Mobile Safari/533.1", browser: "Android Webkit", browserVersion: "4.0", os: "Android", osVersion: "2.3"},
+		{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Mobile/15E148 Safari/604.1", browser: "Mobile Safari", browserVersion: "16.3", os: "iOS", osVersion: "16.3"},
+		{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/110.0.5481.114 Mobile/15E148 Safari/604.1", browser: "Chrome", browserVersion: "110.0", os: "iOS", osVersion: "16.3"},
+		{ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Safari/605.1.15", browser: "Safari", browserVersion: "16.3", os: "Mac OS X", osVersion: "10.15"},
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36 Edg/109.0.1518.78", browser: "Edge", browserVersion: "109.0", os: "Windows", osVersion: "10.0"},
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36 OPR/95.0.4635.46", browser: "Opera", browserVersion: "95.0", os: "Windows", osVersion: "10.0"},
+		{ua: "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko", browser: "Internet Explorer", browserVersion: "11.0", os: "Windows", osVersion: "6.1"},
+		{ua: "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0", browser: "Firefox", browserVersion: "115.0", os: "Linux", osVersion: ""},
+		{ua: "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36", browser: "Chrome", browserVersion: "109.0", os: "Chrome OS", osVersion: "14541.0"},
+		{ua: "Mozilla/5.0 (compatible; MSIE 10.0; Windows Phone 8.0; Trident/6.0; IEMobile/10.0; ARM; Touch; NOKIA; Lumia 920)", browser: "IEMobile", browserVersion: "10.0", os: "Windows Phone", osVersion: "8.0"},
+		{ua: "Opera/9.80 (J2ME/MIDP; Opera Mini/7.1.32052/29.3417; U; en) Presto/2.8.119 Version/11.10", browser: "Opera Mini", browserVersion: "7.1", os: "Symbian OS", osVersion: "7.0"},
+		{ua: "Opera/9.80 (Android 2.3.3; Linux; Opera Mobi/ADR-1111101157; U; es-ES) Presto/2.9.201 Version/11.50", browser: "Opera Mobile", browserVersion: "11.50", os: "Android", osVersion: "2.3"},
+		{ua: "Mozilla/5.0 (BlackBerry; U; BlackBerry 9900; en) AppleWebKit/534.11+ (KHTML, like Gecko) Version/7.1.0.346 Mobile Safari/534.11+", browser: "Mobile Safari", browserVersion: "7.1", os: "BlackBerry OS", osVersion: "7.1"},
+		{ua: testUAs[6], browser: "Access NetFront", browserVersion: "3.2", os: "Symbian OS", osVersion: "7.0"},
+	} {
+		got := []string{}
+		for _, name := range []string{"advertised_browser", "advertised_browser_version", "advertised_device_os", "advertised_device_os_version"} {
+			value, _ := dev.VirtualCapability(name, test.ua)
+			got = append(got, value)
+		}
+		if want := []string{test.browser, test.browserVersion, test.os, test.osVersion}; !reflect.DeepEqual(got, want) {
+			t.Errorf("advertised for %q = %q, want %q", test.ua, got, want)
+		}
+	}
+}
+
+func TestVirtualDeviceNames(t *testing.T) {
+	for _, test := range []struct {
+		capabilities map[string]string
+		complete     string
+		name         string
+	}{
+		{capabilities: map[string]string{"brand_name": "Samsung", "model_name": "SM-G991B", "marketing_name": "Galaxy S21"}, complete: "Samsung SM-G991B (Galaxy S21)", name: "Samsung Galaxy S21"},
+		{capabilities: map[string]string{"brand_name": "Nokia", "model_name": "6600"}, complete: "Nokia 6600", name: "Nokia 6600"},
+		{capabilities: map[string]string{"model_name": "Chrome"}, complete: "Chrome", name: "Chrome"},
+		{capabilities: map[string]string{}, complete: "", name: ""},
+	} {
+		dev := &Device{Id: "test", Capabilities: test.capabilities}
+		if got, _ := dev.VirtualCapability("complete_device_name", ""); got != test.complete {
+			t.Errorf("complete_device_name with %v = %q, want %q", test.capabilities, got, test.complete)
+		}
+		if got, _ := dev.VirtualCapability("device_name", ""); got != test.name {
+			t.Errorf("device_name with %v = %q, want %q", test.capabilities, got, test.name)
+		}
+	}
+}
+
+func TestVirtualCapabilities(t *testing.T) {
+	r := loadTestRepository(t, "all", WithRetention(RETAIN_ALL))
+	dev := r.Match(testUAs[0])
+	if dev == nil {
+		t.Fatalf("Match(%q) = nil", testUAs[0])
+	}
+	values := dev.VirtualCapabilities(testUAs[0])
+	if names := VirtualCapabilityNames(); len(values) != len(names) {
+		t.Errorf("VirtualCapabilities returned %d values for %d names", len(values), len(names))
+	}
+	for name, want := range map[string]string{
+		"is_android":           "true",
+		"is_mobile":            "true",
+		"advertised_browser":   "Android Webkit",
+		"complete_device_name": "Samsung GT-I9100",
+	} {
+		if values[name] != want {
+			t.Errorf("%s = %q, want %q", name, values[name], want)
+		}
+	}
+	if _, err := dev.VirtualCapability("no_such_capability", testUAs[0]); !errors.Is(err, ErrUnknownCapability) {
+		t.Errorf("VirtualCapability of an unknown name: error = %v, want ErrUnknownCapability", err)
+	}
+	if _, found := LookupCapability("ux_full_desktop"); !found {
+		t.Error("ux_full_desktop is not a known capability")
+	}
+}